@@ -0,0 +1,273 @@
+package eip712
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// ErrAuditLogTampered is returned when an audit log's hash chain is broken
+var ErrAuditLogTampered = errors.New("audit log hash chain broken")
+
+// AuditEntry describes one signature a signer produced
+type AuditEntry struct {
+	Time        time.Time                `json:"time"`
+	Signer      common.Address           `json:"signer"`
+	Domain      apitypes.TypedDataDomain `json:"domain"`
+	PrimaryType string                   `json:"primaryType"`
+	// Hash is the signed EIP-712 digest
+	Hash string `json:"hash"`
+	// TypedData is the full typed data in the JSON form of eth_signTypedData_v4
+	TypedData json.RawMessage `json:"typedData"`
+	Signature string          `json:"signature"`
+}
+
+// AuditSink receives an entry for every signature a signer produces. It is
+// called before the signature is returned, and if it fails the signature
+// is withheld and its error returned instead, so nothing is signed without
+// a record. Sinks must be safe for concurrent use.
+//
+// Signer, FastSigner and ExternalSigner record to a sink set with
+// SetAuditSink; OptimizedSigner records through its embedded Signer.
+type AuditSink interface {
+	Record(entry *AuditEntry) error
+}
+
+// SetAuditSink makes the signer record every signature in sink; nil stops
+// recording. It is safe to call while the signer is in use.
+func (s *Signer) SetAuditSink(sink AuditSink) {
+	s.key.mu.Lock()
+	defer s.key.mu.Unlock()
+	s.audit = sink
+}
+
+// SetAuditSink makes the signer record every signature in sink; nil stops
+// recording. It is safe to call while the signer is in use.
+func (s *FastSigner) SetAuditSink(sink AuditSink) {
+	s.key.mu.Lock()
+	defer s.key.mu.Unlock()
+	s.audit = sink
+}
+
+// SetAuditSink makes the signer record every signature in sink; nil stops
+// recording. It is safe to call while the signer is in use.
+func (s *ExternalSigner) SetAuditSink(sink AuditSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = sink
+}
+
+// signDigest signs hash, the digest of data, and records the signature
+func (s *Signer) signDigest(ctx context.Context, data TypedData, hash []byte) (*Signature, error) {
+	sig, err := s.key.signContext(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	s.key.mu.RLock()
+	sink := s.audit
+	s.key.mu.RUnlock()
+	if err := recordAudit(sink, s.address, data, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// signDigest signs hash, the digest of data, and records the signature
+func (s *FastSigner) signDigest(ctx context.Context, data TypedData, hash []byte) (*Signature, error) {
+	sig, err := s.key.signContext(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	s.key.mu.RLock()
+	sink := s.audit
+	s.key.mu.RUnlock()
+	if err := recordAudit(sink, s.address, data, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// recordAudit records a signature by address over data in sink, if any
+func recordAudit(sink AuditSink, address common.Address, data TypedData, sig *Signature) error {
+	if sink == nil {
+		return nil
+	}
+
+	typedData, err := json.Marshal(externalTypedData(data.Domain, data.Types, data.PrimaryType, data.Message))
+	if err != nil {
+		return fmt.Errorf("failed to encode typed data for audit: %w", err)
+	}
+	entry := &AuditEntry{
+		Time:        SystemClock.Now().UTC(),
+		Signer:      address,
+		Domain:      domainToAPITypesStatic(data.Domain),
+		PrimaryType: data.PrimaryType,
+		Hash:        sig.Hash,
+		TypedData:   typedData,
+		Signature:   sig.Bytes,
+	}
+	if err := sink.Record(entry); err != nil {
+		return fmt.Errorf("failed to record signature: %w", err)
+	}
+	return nil
+}
+
+// auditRecord is one line of a FileAuditSink log. Prev is the keccak256 of
+// the previous line, or zero for the first.
+type auditRecord struct {
+	Seq   uint64      `json:"seq"`
+	Prev  common.Hash `json:"prev"`
+	Entry *AuditEntry `json:"entry"`
+}
+
+// FileAuditSink appends entries to a JSON-lines file, chaining each line to
+// the keccak256 hash of the one before it, so that editing, reordering or
+// deleting any entry other than the last ones breaks the chain. Keep Head
+// somewhere else, such as another system's log, to detect truncation too.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  uint64
+	head common.Hash
+}
+
+// NewFileAuditSink opens or creates the log at path, verifying any existing
+// entries before appending to them
+//
+// Example:
+//
+//	sink, err := NewFileAuditSink("/var/log/signer/audit.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer sink.Close()
+//	signer.SetAuditSink(sink)
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	count, head, err := verifyAuditChain(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileAuditSink{file: file, seq: uint64(count), head: head}, nil
+}
+
+// Record appends entry to the log and syncs it to disk
+func (s *FileAuditSink) Record(entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return errors.New("audit log is closed")
+	}
+	line, err := json.Marshal(auditRecord{Seq: s.seq, Prev: s.head, Entry: entry})
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	s.seq++
+	s.head = crypto.Keccak256Hash(line)
+	return nil
+}
+
+// Head returns the hash of the last entry written, which commits to the
+// whole log
+func (s *FileAuditSink) Head() common.Hash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head
+}
+
+// Close closes the log file
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// VerifyAuditLog checks the hash chain of the log at path and returns its
+// entries. It fails with ErrAuditLogTampered at the first broken link.
+func VerifyAuditLog(path string) ([]*AuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []*AuditEntry
+	_, _, err = scanAuditChain(file, func(record *auditRecord) {
+		entries = append(entries, record.Entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// verifyAuditChain checks a log and returns its entry count and head hash
+func verifyAuditChain(file *os.File) (int, common.Hash, error) {
+	return scanAuditChain(file, func(*auditRecord) {})
+}
+
+// scanAuditChain reads a log line by line, checking each line's sequence
+// number and link to the previous line, and passes every record to visit
+func scanAuditChain(file *os.File, visit func(*auditRecord)) (int, common.Hash, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var (
+		count int
+		head  common.Hash
+	)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			return 0, common.Hash{}, fmt.Errorf("%w: blank line %d", ErrAuditLogTampered, count+1)
+		}
+
+		var record auditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, common.Hash{}, fmt.Errorf("%w: line %d: %v", ErrAuditLogTampered, count+1, err)
+		}
+		if record.Seq != uint64(count) {
+			return 0, common.Hash{}, fmt.Errorf("%w: line %d has sequence %d", ErrAuditLogTampered, count+1, record.Seq)
+		}
+		if record.Prev != head {
+			return 0, common.Hash{}, fmt.Errorf("%w: line %d does not follow line %d", ErrAuditLogTampered, count+1, count)
+		}
+
+		visit(&record)
+		head = crypto.Keccak256Hash(line)
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, common.Hash{}, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return count, head, nil
+}
@@ -0,0 +1,221 @@
+package eip712
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAuditSink keeps entries in memory
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	entries []*AuditEntry
+	err     error
+}
+
+func (s *memoryAuditSink) Record(entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestSignerAuditSink(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	sink := &memoryAuditSink{}
+	signer.SetAuditSink(sink)
+
+	domain := createTestDomain("Mail", "1", 1)
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+	sig, err := signer.SignTypedData(domain, createMailTypes(), "Mail", message)
+	require.NoError(t, err)
+
+	require.Len(t, sink.entries, 1)
+	entry := sink.entries[0]
+	assert.Equal(t, signer.Address(), entry.Signer)
+	assert.Equal(t, "Mail", entry.PrimaryType)
+	assert.Equal(t, "Mail", entry.Domain.Name)
+	assert.Equal(t, sig.Hash, entry.Hash)
+	assert.Equal(t, sig.Bytes, entry.Signature)
+	assert.False(t, entry.Time.IsZero())
+
+	// The recorded typed data hashes to the signed digest
+	var typedData apitypes.TypedData
+	require.NoError(t, json.Unmarshal(entry.TypedData, &typedData))
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, sig.Hash, "0x"+common.Bytes2Hex(hash))
+
+	// Other signing paths record too
+	_, err = signer.SignSeaportOrder(createTestSeaportOrder(1), SeaportV16Address)
+	require.NoError(t, err)
+	_, err = NewSignerPool(signer, 2).SignBatch(context.Background(), createPermitBatch(signer.Address(), 10))
+	require.NoError(t, err)
+	assert.Len(t, sink.entries, 12)
+	assert.Equal(t, "OrderComponents", sink.entries[1].PrimaryType)
+}
+
+func TestAuditSinkFailureWithholdsSignature(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	sinkErr := errors.New("disk full")
+	signer.SetAuditSink(&memoryAuditSink{err: sinkErr})
+
+	sig, err := signer.SignTypedData(createTestDomain("Mail", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"))
+	assert.ErrorIs(t, err, sinkErr)
+	assert.Nil(t, sig)
+}
+
+func TestFastAndExternalSignerAuditSink(t *testing.T) {
+	domain := createTestDomain("Mail", "1", 1)
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	fast, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	fastSink := &memoryAuditSink{}
+	fast.SetAuditSink(fastSink)
+
+	_, err = fast.SignTypedDataFast(domain, createMailTypes(), "Mail", message)
+	require.NoError(t, err)
+	_, err = fast.SignPermitFast(testUSDC, "USD Coin", "2", testRouter, common.Big1, common.Big0, common.Big3)
+	require.NoError(t, err)
+	fast.SignEach(context.Background(), createPermitBatch(fast.Address(), 5), 2)
+	assert.Len(t, fastSink.entries, 7)
+
+	_, url := newStubClef(t, testPrivateKey1)
+	external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+	require.NoError(t, err)
+	defer external.Close()
+	externalSink := &memoryAuditSink{}
+	external.SetAuditSink(externalSink)
+
+	sig, err := external.SignTypedData(domain, createMailTypes(), "Mail", message)
+	require.NoError(t, err)
+	require.Len(t, externalSink.entries, 1)
+	assert.Equal(t, sig.Bytes, externalSink.entries[0].Signature)
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	sink, err := NewFileAuditSink(path)
+	require.NoError(t, err)
+	signer.SetAuditSink(sink)
+	assert.Equal(t, common.Hash{}, sink.Head())
+
+	items := createPermitBatch(signer.Address(), 3)
+	for _, item := range items {
+		_, err := signer.SignTypedData(item.Domain, item.Types, item.PrimaryType, item.Message)
+		require.NoError(t, err)
+	}
+	head := sink.Head()
+	require.NoError(t, sink.Close())
+	assert.Error(t, sink.Record(&AuditEntry{}))
+
+	// Reopening continues the chain
+	sink, err = NewFileAuditSink(path)
+	require.NoError(t, err)
+	assert.Equal(t, head, sink.Head())
+	signer.SetAuditSink(sink)
+	_, err = signer.SignTypedData(items[0].Domain, items[0].Types, items[0].PrimaryType, items[0].Message)
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	entries, err := VerifyAuditLog(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "Permit", entries[3].PrimaryType)
+	assert.Equal(t, signer.Address(), entries[0].Signer)
+}
+
+func TestFileAuditSinkTamperDetection(t *testing.T) {
+	writeLog := func(t *testing.T) (string, [][]byte) {
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		signer, err := NewSigner(testPrivateKey1, 1)
+		require.NoError(t, err)
+		sink, err := NewFileAuditSink(path)
+		require.NoError(t, err)
+		signer.SetAuditSink(sink)
+		for _, item := range createPermitBatch(signer.Address(), 4) {
+			_, err := signer.SignTypedData(item.Domain, item.Types, item.PrimaryType, item.Message)
+			require.NoError(t, err)
+		}
+		require.NoError(t, sink.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return path, bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+	}{
+		{"edit", func(lines [][]byte) [][]byte {
+			lines[1] = bytes.Replace(lines[1], []byte(`"primaryType":"Permit"`), []byte(`"primaryType":"Mail"`), 1)
+			return lines
+		}},
+		{"delete", func(lines [][]byte) [][]byte {
+			return append(lines[:1], lines[2:]...)
+		}},
+		{"delete first", func(lines [][]byte) [][]byte {
+			return lines[1:]
+		}},
+		{"reorder", func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}},
+		{"garbage", func(lines [][]byte) [][]byte {
+			return append(lines, []byte("{"))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, lines := writeLog(t)
+			tampered := append(bytes.Join(tt.tamper(lines), []byte("\n")), '\n')
+			require.NoError(t, os.WriteFile(path, tampered, 0600))
+
+			_, err := VerifyAuditLog(path)
+			assert.ErrorIs(t, err, ErrAuditLogTampered)
+			_, err = NewFileAuditSink(path)
+			assert.ErrorIs(t, err, ErrAuditLogTampered)
+		})
+	}
+}
+
+func TestFileAuditSinkConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	signer.SetAuditSink(sink)
+
+	for _, result := range signer.SignEach(context.Background(), createPermitBatch(signer.Address(), 50), 8) {
+		require.NoError(t, result.Err)
+	}
+
+	entries, err := VerifyAuditLog(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 50)
+}
@@ -0,0 +1,166 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SignResult is the outcome of signing one batch item
+type SignResult struct {
+	Signature *Signature
+	Err       error
+}
+
+// VerificationRequest is one signature to check in a batch
+type VerificationRequest struct {
+	TypedData
+	Signature *Signature
+	// Signer is the address the signature is expected to recover to
+	Signer common.Address
+}
+
+// VerificationResult is the outcome of checking one batch item. Valid is
+// false both for a signature by another address and for an item whose
+// check failed with Err.
+type VerificationResult struct {
+	Valid     bool
+	Recovered common.Address
+	Err       error
+}
+
+// SignEach signs every item with the fast encoder on up to workers
+// goroutines, zero or less meaning GOMAXPROCS. Items sharing a types map
+// are validated and completed once for the whole batch. The results are in
+// item order; unlike SignerPool.SignBatch, a failed item does not stop the
+// others. Once ctx is done, the remaining items fail with its error.
+//
+// Example:
+//
+//	results := signer.SignEach(ctx, orders, 0)
+//	for i, result := range results {
+//	    if result.Err != nil {
+//	        log.Printf("order %d: %v", i, result.Err)
+//	    }
+//	}
+func (s *FastSigner) SignEach(ctx context.Context, items []TypedData, workers int) []SignResult {
+	results := make([]SignResult, len(items))
+	schemas := prepareSchemas(len(items), func(i int) TypedData { return items[i] })
+
+	runBatch(len(items), workers, func(encoder *FastTypedDataEncoder, i int) {
+		hash, err := batchHash(ctx, encoder, items[i], schemas)
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		results[i].Signature, results[i].Err = s.signDigest(ctx, items[i], hash)
+	})
+	return results
+}
+
+// VerifyBatch checks every request with the fast encoder on up to workers
+// goroutines, zero or less meaning GOMAXPROCS, and returns the results in
+// request order. It shares schema preparation across requests like
+// FastSigner.SignEach.
+func VerifyBatch(ctx context.Context, requests []VerificationRequest, workers int) []VerificationResult {
+	results := make([]VerificationResult, len(requests))
+	schemas := prepareSchemas(len(requests), func(i int) TypedData { return requests[i].TypedData })
+
+	runBatch(len(requests), workers, func(encoder *FastTypedDataEncoder, i int) {
+		request := requests[i]
+		if request.Signature == nil {
+			results[i].Err = errors.New("signature is nil")
+			return
+		}
+		hash, err := batchHash(ctx, encoder, request.TypedData, schemas)
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		recovered, err := recoverHash(hash, request.Signature.Bytes)
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		results[i].Recovered = recovered
+		results[i].Valid = recovered == request.Signer
+	})
+	return results
+}
+
+// prepareSchemas prepares each distinct types map in a batch once, before
+// any worker starts
+func prepareSchemas(n int, typedData func(i int) TypedData) map[schemaRef]*preparedSchema {
+	schemas := make(map[schemaRef]*preparedSchema)
+	for i := 0; i < n; i++ {
+		data := typedData(i)
+		ref := newSchemaRef(data)
+		if _, ok := schemas[ref]; !ok {
+			schemas[ref] = globalEncoderCache.prepare(data.Types, ref.fields)
+		}
+	}
+	return schemas
+}
+
+// newSchemaRef returns the schemaRef of a batch item
+func newSchemaRef(data TypedData) schemaRef {
+	return schemaRef{
+		types:  reflect.ValueOf(data.Types).Pointer(),
+		fields: data.Domain.FieldSet(),
+	}
+}
+
+// batchHash hashes one batch item with a worker's encoder
+func batchHash(ctx context.Context, encoder *FastTypedDataEncoder, data TypedData, schemas map[schemaRef]*preparedSchema) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	schema := schemas[newSchemaRef(data)]
+	if schema.err != nil {
+		return nil, schema.err
+	}
+
+	encoder.reset(data.Domain, nil, data.PrimaryType, data.Message)
+	encoder.usePrepared(schema)
+	hash, err := encoder.HashContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return hash, nil
+}
+
+// runBatch calls fn for each index in [0, n) on up to workers goroutines,
+// zero or less meaning GOMAXPROCS, each with its own encoder. It is the
+// worker pool behind every batch API.
+func runBatch(n, workers int, fn func(encoder *FastTypedDataEncoder, i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			encoder := &FastTypedDataEncoder{cache: globalEncoderCache}
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				fn(encoder, i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,140 @@
+package eip712
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createPermitBatch returns n permits for the same token sharing one types map
+func createPermitBatch(owner common.Address, n int) []TypedData {
+	types := createPermitTypes()
+	items := make([]TypedData, n)
+	for i := range items {
+		items[i] = createTestPermit(owner, testSpender, big.NewInt(int64(1000+i)), big.NewInt(int64(i)))
+		items[i].Types = types
+	}
+	return items
+}
+
+func TestSignEach(t *testing.T) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	items := createPermitBatch(signer.Address(), 100)
+	// A second schema in the same batch
+	items = append(items, TypedData{
+		Domain:      createTestDomain("Mail", "1", 1),
+		Types:       createMailTypes(),
+		PrimaryType: "Mail",
+		Message:     createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"),
+	})
+
+	for _, workers := range []int{0, 1, 7} {
+		results := signer.SignEach(context.Background(), items, workers)
+		require.Len(t, results, len(items))
+
+		for i, result := range results {
+			require.NoError(t, result.Err, "item %d", i)
+			want, err := signer.SignTypedDataFast(items[i].Domain, items[i].Types, items[i].PrimaryType, items[i].Message)
+			require.NoError(t, err)
+			assert.Equal(t, want.Bytes, result.Signature.Bytes, "item %d", i)
+		}
+	}
+}
+
+func TestSignEachPerItemErrors(t *testing.T) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	items := createPermitBatch(signer.Address(), 5)
+	items[1].Message = Message{"owner": "not an address"}
+	items[3].Types = map[string][]Type{
+		"A": {{Name: "b", Type: "B"}},
+		"B": {{Name: "a", Type: "A"}},
+	}
+	items[3].PrimaryType = "A"
+
+	results := signer.SignEach(context.Background(), items, 2)
+	require.Len(t, results, 5)
+	for _, i := range []int{0, 2, 4} {
+		assert.NoError(t, results[i].Err, "item %d", i)
+		assert.NotNil(t, results[i].Signature, "item %d", i)
+	}
+	assert.Error(t, results[1].Err)
+	assert.Nil(t, results[1].Signature)
+	assert.ErrorContains(t, results[3].Err, "cyclic")
+}
+
+func TestSignEachCancelled(t *testing.T) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := signer.SignEach(ctx, createPermitBatch(signer.Address(), 10), 0)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+
+	assert.Empty(t, signer.SignEach(context.Background(), nil, 0))
+}
+
+func TestVerifyBatch(t *testing.T) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	items := createPermitBatch(signer.Address(), 20)
+	results := signer.SignEach(context.Background(), items, 0)
+
+	requests := make([]VerificationRequest, len(items))
+	for i, item := range items {
+		require.NoError(t, results[i].Err)
+		requests[i] = VerificationRequest{TypedData: item, Signature: results[i].Signature, Signer: signer.Address()}
+	}
+	// Wrong expected signer, a signature over another item, and no signature
+	requests[4].Signer = common.HexToAddress(testAddress2)
+	requests[9].Signature = results[10].Signature
+	requests[15].Signature = nil
+
+	verified := VerifyBatch(context.Background(), requests, 3)
+	require.Len(t, verified, len(requests))
+	for i, result := range verified {
+		switch i {
+		case 4:
+			assert.NoError(t, result.Err)
+			assert.False(t, result.Valid)
+			assert.Equal(t, signer.Address(), result.Recovered)
+		case 9:
+			assert.NoError(t, result.Err)
+			assert.False(t, result.Valid)
+			assert.NotEqual(t, signer.Address(), result.Recovered)
+		case 15:
+			assert.Error(t, result.Err)
+			assert.False(t, result.Valid)
+		default:
+			assert.NoError(t, result.Err, "item %d", i)
+			assert.True(t, result.Valid, "item %d", i)
+		}
+	}
+}
+
+func BenchmarkSignEach(b *testing.B) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(b, err)
+	items := createPermitBatch(signer.Address(), 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, result := range signer.SignEach(context.Background(), items, 0) {
+			if result.Err != nil {
+				b.Fatal(result.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package eip712
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrChainMismatch is returned by a chain-enforcing signer when a domain
+	// is not bound to the signer's chain
+	ErrChainMismatch = errors.New("domain chain ID does not match signer")
+	// ErrUnknownChain is returned when a domain's chain is not registered
+	ErrUnknownChain = errors.New("unknown chain")
+)
+
+// Chain describes an EVM chain
+type Chain struct {
+	ID      uint64
+	Name    string
+	Testnet bool
+}
+
+// String returns the chain name and ID, e.g. "Polygon (137)"
+func (c Chain) String() string {
+	return fmt.Sprintf("%s (%d)", c.Name, c.ID)
+}
+
+// knownChains seed DefaultChainRegistry
+var knownChains = []Chain{
+	{ID: 1, Name: "Ethereum"},
+	{ID: 10, Name: "OP Mainnet"},
+	{ID: 56, Name: "BNB Smart Chain"},
+	{ID: 100, Name: "Gnosis"},
+	{ID: 137, Name: "Polygon"},
+	{ID: 250, Name: "Fantom"},
+	{ID: 324, Name: "zkSync Era"},
+	{ID: 1101, Name: "Polygon zkEVM"},
+	{ID: 5000, Name: "Mantle"},
+	{ID: 8453, Name: "Base"},
+	{ID: 42161, Name: "Arbitrum One"},
+	{ID: 42220, Name: "Celo"},
+	{ID: 43114, Name: "Avalanche C-Chain"},
+	{ID: 59144, Name: "Linea"},
+	{ID: 81457, Name: "Blast"},
+	{ID: 534352, Name: "Scroll"},
+	{ID: 7777777, Name: "Zora"},
+	{ID: 17000, Name: "Holesky", Testnet: true},
+	{ID: 80002, Name: "Polygon Amoy", Testnet: true},
+	{ID: 84532, Name: "Base Sepolia", Testnet: true},
+	{ID: 421614, Name: "Arbitrum Sepolia", Testnet: true},
+	{ID: 11155111, Name: "Sepolia", Testnet: true},
+	{ID: 11155420, Name: "OP Sepolia", Testnet: true},
+	{ID: 31337, Name: "Hardhat", Testnet: true},
+}
+
+// DefaultChainRegistry knows the major mainnets and testnets. Register
+// private or newer chains on it, or build a separate registry.
+var DefaultChainRegistry = NewChainRegistry(knownChains...)
+
+// ChainRegistry maps chain IDs to chains. It is safe for concurrent use.
+type ChainRegistry struct {
+	mu     sync.RWMutex
+	chains map[uint64]Chain
+}
+
+// NewChainRegistry returns a registry holding chains
+func NewChainRegistry(chains ...Chain) *ChainRegistry {
+	r := &ChainRegistry{chains: make(map[uint64]Chain, len(chains))}
+	for _, chain := range chains {
+		r.chains[chain.ID] = chain
+	}
+	return r
+}
+
+// Register adds or replaces a chain
+func (r *ChainRegistry) Register(chain Chain) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chains[chain.ID] = chain
+}
+
+// Lookup returns the chain with the given ID
+func (r *ChainRegistry) Lookup(chainID *big.Int) (Chain, bool) {
+	if chainID == nil || !chainID.IsUint64() {
+		return Chain{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chain, ok := r.chains[chainID.Uint64()]
+	return chain, ok
+}
+
+// Chains returns the registered chains ordered by ID
+func (r *ChainRegistry) Chains() []Chain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chains := make([]Chain, 0, len(r.chains))
+	for _, chain := range r.chains {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ID < chains[j].ID })
+	return chains
+}
+
+// Describe returns a human-readable name for chainID, such as
+// "Polygon (137)" or "unknown chain 999"
+func (r *ChainRegistry) Describe(chainID *big.Int) string {
+	if chainID == nil {
+		return "no chain"
+	}
+	if chain, ok := r.Lookup(chainID); ok {
+		return chain.String()
+	}
+	return fmt.Sprintf("unknown chain %s", chainID)
+}
+
+// ValidateDomain checks that domain is bound to a registered chain
+func (r *ChainRegistry) ValidateDomain(domain Domain) error {
+	if !domain.FieldSet().Has(DomainFieldChainID) || domain.ChainID == nil {
+		return fmt.Errorf("%w: domain has no chainId", ErrUnknownChain)
+	}
+	if _, ok := r.Lookup(domain.ChainID); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChain, domain.ChainID)
+	}
+	return nil
+}
+
+// SetEnforceChain makes the signer refuse typed data whose domain is not
+// bound to the signer's chain ID, including domains without a chainId,
+// which could be replayed on any chain. It is safe to call while the signer
+// is in use.
+//
+// Example:
+//
+//	signer, _ := NewSigner(privateKey, 1)
+//	signer.SetEnforceChain(true)
+//	_, err := signer.SignTypedData(polygonDomain, types, "Permit", message)
+//	// err: domain chain ID does not match signer: domain is for Polygon (137),
+//	// signer is for Ethereum (1)
+func (s *Signer) SetEnforceChain(enforce bool) {
+	s.key.mu.Lock()
+	defer s.key.mu.Unlock()
+	s.enforceChain = enforce
+}
+
+// checkChain applies SetEnforceChain to the domain as it is hashed, so an
+// explicit EIP712Domain type without chainId is refused
+func (s *Signer) checkChain(data TypedData) error {
+	s.key.mu.RLock()
+	enforce := s.enforceChain
+	s.key.mu.RUnlock()
+	if !enforce {
+		return nil
+	}
+	return checkChainID(data, s.chainID)
+}
+
+// SetEnforceChain makes the signer refuse typed data whose domain is not
+// bound to the signer's chain ID, as Signer.SetEnforceChain does. It is
+// safe to call while the signer is in use.
+func (s *ExternalSigner) SetEnforceChain(enforce bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enforceChain = enforce
+}
+
+// checkChainID refuses typed data whose hashed domain is not bound to
+// chainID
+func checkChainID(data TypedData, chainID *big.Int) error {
+	domain := data.Domain
+	if !domain.hashedFields(data.Types).Has(DomainFieldChainID) || domain.ChainID == nil {
+		return fmt.Errorf("%w: domain has no chainId, signer is for %s",
+			ErrChainMismatch, DefaultChainRegistry.Describe(chainID))
+	}
+	if domain.ChainID.Cmp(chainID) != 0 {
+		return fmt.Errorf("%w: domain is for %s, signer is for %s",
+			ErrChainMismatch, DefaultChainRegistry.Describe(domain.ChainID), DefaultChainRegistry.Describe(chainID))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package eip712
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry(t *testing.T) {
+	chain, ok := DefaultChainRegistry.Lookup(big.NewInt(137))
+	require.True(t, ok)
+	assert.Equal(t, "Polygon", chain.Name)
+	assert.False(t, chain.Testnet)
+
+	sepolia, ok := DefaultChainRegistry.Lookup(big.NewInt(11155111))
+	require.True(t, ok)
+	assert.True(t, sepolia.Testnet)
+
+	_, ok = DefaultChainRegistry.Lookup(new(big.Int).Lsh(big.NewInt(1), 70))
+	assert.False(t, ok)
+	_, ok = DefaultChainRegistry.Lookup(nil)
+	assert.False(t, ok)
+
+	assert.Equal(t, "Ethereum (1)", DefaultChainRegistry.Describe(big.NewInt(1)))
+	assert.Equal(t, "unknown chain 999999", DefaultChainRegistry.Describe(big.NewInt(999999)))
+	assert.Equal(t, "no chain", DefaultChainRegistry.Describe(nil))
+
+	chains := DefaultChainRegistry.Chains()
+	for i := 1; i < len(chains); i++ {
+		assert.Less(t, chains[i-1].ID, chains[i].ID)
+	}
+}
+
+func TestChainRegistryRegister(t *testing.T) {
+	registry := NewChainRegistry(Chain{ID: 1, Name: "Ethereum"})
+	assert.ErrorIs(t, registry.ValidateDomain(createTestDomain("App", "1", 424242)), ErrUnknownChain)
+
+	registry.Register(Chain{ID: 424242, Name: "Devnet", Testnet: true})
+	assert.NoError(t, registry.ValidateDomain(createTestDomain("App", "1", 424242)))
+	assert.Equal(t, "Devnet (424242)", registry.Describe(big.NewInt(424242)))
+	assert.Len(t, registry.Chains(), 2)
+
+	assert.ErrorIs(t, registry.ValidateDomain(Domain{Name: "App", Version: "1"}), ErrUnknownChain)
+	assert.ErrorIs(t, registry.ValidateDomain(Domain{
+		Name:    "App",
+		Version: "1",
+		ChainID: big.NewInt(1),
+		Fields:  DomainFieldName | DomainFieldVersion,
+	}), ErrUnknownChain, "a chainId left out of the domain type is not bound")
+}
+
+func TestSignerEnforceChain(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	// Without enforcement any chain is signed
+	_, err = signer.SignTypedData(createTestDomain("Mail", "1", 137), types, "Mail", message)
+	require.NoError(t, err)
+
+	signer.SetEnforceChain(true)
+	_, err = signer.SignTypedData(createTestDomain("Mail", "1", 1), types, "Mail", message)
+	assert.NoError(t, err)
+
+	_, err = signer.SignTypedData(createTestDomain("Mail", "1", 137), types, "Mail", message)
+	assert.ErrorIs(t, err, ErrChainMismatch)
+	assert.EqualError(t, err, "domain chain ID does not match signer: domain is for Polygon (137), signer is for Ethereum (1)")
+
+	_, err = signer.SignTypedData(Domain{Name: "Mail", Version: "1"}, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrChainMismatch)
+
+	// Helpers that build their domain from the signer's chain are unaffected
+	_, err = signer.SignPermit(testUSDC, "USD Coin", "2", testRouter, big.NewInt(1), big.NewInt(0), big.NewInt(1893456000))
+	assert.NoError(t, err)
+	_, err = signer.SignSeaportOrder(createTestSeaportOrder(1), SeaportV16Address)
+	assert.NoError(t, err)
+
+	items := createPermitBatch(signer.Address(), 2)
+	items[1].Domain = createTestDomain("USD Coin", "2", 10)
+	_, err = NewSignerPool(signer, 1).SignTypedData(items[1].Domain, items[1].Types, items[1].PrimaryType, items[1].Message)
+	assert.ErrorIs(t, err, ErrChainMismatch)
+}
+
+func TestSignerEnforceChainExplicitDomainType(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	signer.SetEnforceChain(true)
+
+	// The domain carries the signer's chain ID, but the explicit
+	// EIP712Domain type leaves it out of the hash
+	types := createMailTypes()
+	types["EIP712Domain"] = []Type{{Name: "name", Type: "string"}, {Name: "version", Type: "string"}}
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	_, err = signer.SignTypedData(createTestDomain("Mail", "1", 1), types, "Mail", message)
+	assert.ErrorIs(t, err, ErrChainMismatch)
+
+	types["EIP712Domain"] = DomainFields(DomainFieldName | DomainFieldVersion | DomainFieldChainID).Types()
+	_, err = signer.SignTypedData(createTestDomain("Mail", "1", 1), types, "Mail", message)
+	assert.NoError(t, err)
+}
+
+func TestAllowChainIDsDescribesChain(t *testing.T) {
+	err := AllowChainIDs(1).Check(TypedData{Domain: createTestDomain("App", "1", 8453)})
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+	assert.ErrorContains(t, err, "Base (8453) is not allowed")
+}
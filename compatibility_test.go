@@ -14,13 +14,10 @@ import (
 
 type TestVector struct {
 	Name         string                 `json:"name"`
-	Domain       json.RawMessage        `json:"domain"`
-	Types        map[string][]Type      `json:"types"`
-	PrimaryType  string                 `json:"primaryType"`
-	Message      json.RawMessage        `json:"message"`
 	ExpectedHash string                 `json:"expectedHash,omitempty"`
 	Signature    *SignatureVector       `json:"signature,omitempty"`
 	SignerAddress string                `json:"signerAddress,omitempty"`
+	TypedData    TypedData              `json:"-"`
 }
 
 type SignatureVector struct {
@@ -42,47 +39,17 @@ func loadTestVectors(t *testing.T) TestVectors {
 	err = json.Unmarshal(data, &vectors)
 	require.NoError(t, err)
 	
-	return vectors
-}
-
-func parseDomain(t *testing.T, raw json.RawMessage) Domain {
-	var d struct {
-		Name              string `json:"name"`
-		Version           string `json:"version"`
-		ChainID           *int64 `json:"chainId"`
-		VerifyingContract string `json:"verifyingContract"`
-		Salt              string `json:"salt"`
+	// Each vector is also a typed data document
+	var documents struct {
+		Vectors []TypedData `json:"vectors"`
 	}
-	
-	err := json.Unmarshal(raw, &d)
+	err = json.Unmarshal(data, &documents)
 	require.NoError(t, err)
-	
-	domain := Domain{
-		Name:    d.Name,
-		Version: d.Version,
-	}
-	
-	if d.ChainID != nil {
-		domain.ChainID = big.NewInt(*d.ChainID)
+	for i := range vectors.Vectors {
+		vectors.Vectors[i].TypedData = documents.Vectors[i]
 	}
 	
-	if d.VerifyingContract != "" {
-		domain.VerifyingContract = common.HexToAddress(d.VerifyingContract)
-	}
-	
-	if d.Salt != "" {
-		saltBytes, err := hex.DecodeString(strings.TrimPrefix(d.Salt, "0x"))
-		require.NoError(t, err)
-		copy(domain.Salt[:], saltBytes)
-	}
-	
-	return domain
-}
-
-func parseMessage(raw json.RawMessage) Message {
-	var msg map[string]interface{}
-	json.Unmarshal(raw, &msg)
-	return Message(msg)
+	return vectors
 }
 
 func TestCompatibilityWithKnownVectors(t *testing.T) {
@@ -94,11 +61,11 @@ func TestCompatibilityWithKnownVectors(t *testing.T) {
 	
 	for _, vector := range vectors.Vectors {
 		t.Run(vector.Name, func(t *testing.T) {
-			domain := parseDomain(t, vector.Domain)
-			message := parseMessage(vector.Message)
+			domain, message := vector.TypedData.Domain, vector.TypedData.Message
+			types, primaryType := vector.TypedData.Types, vector.TypedData.PrimaryType
 			
 			// Test signing
-			sig, err := signer.SignTypedData(domain, vector.Types, vector.PrimaryType, message)
+			sig, err := signer.SignTypedData(domain, types, primaryType, message)
 			require.NoError(t, err)
 			require.NotNil(t, sig)
 			
@@ -108,7 +75,7 @@ func TestCompatibilityWithKnownVectors(t *testing.T) {
 			}
 			
 			// Test signature recovery
-			recovered, err := sig.Recover(domain, vector.Types, vector.PrimaryType, message)
+			recovered, err := sig.Recover(domain, types, primaryType, message)
 			require.NoError(t, err)
 			require.Equal(t, signer.Address(), recovered)
 			
@@ -129,7 +96,7 @@ func TestCompatibilityWithKnownVectors(t *testing.T) {
 				sigBytes[64] = vector.Signature.V
 				knownSig.Bytes = "0x" + hex.EncodeToString(sigBytes)
 				
-				recovered, err := knownSig.Recover(domain, vector.Types, vector.PrimaryType, message)
+				recovered, err := knownSig.Recover(domain, types, primaryType, message)
 				require.NoError(t, err)
 				require.Equal(t, common.HexToAddress(vector.SignerAddress), recovered)
 			}
@@ -0,0 +1,125 @@
+package eip712
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ContextTypedDataSigner is a TypedDataSigner whose signing can be cancelled.
+// *Signer and *ExternalSigner implement it.
+type ContextTypedDataSigner interface {
+	TypedDataSigner
+	SignTypedDataContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error)
+}
+
+var (
+	_ ContextTypedDataSigner = (*Signer)(nil)
+	_ ContextTypedDataSigner = (*ExternalSigner)(nil)
+)
+
+// SignTypedDataContext is SignTypedData with a context. ctx is checked
+// before and after hashing and is passed to a ContextKeySigner backend, so
+// a remote signature request is abandoned when ctx is done.
+func (s *Signer) SignTypedDataContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.checkPolicies(TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}); err != nil {
+		return nil, err
+	}
+
+	// Validate for cyclic structures
+	if err := validateNoCycles(types); err != nil {
+		return nil, err
+	}
+	// Hash the typed data
+	hash, err := typedDataHash(domain, types, primaryType, message, s.buildDomainTypes(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	// Sign the hash
+	return s.signDigest(ctx, TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}, hash)
+}
+
+// SignTypedDataContext sends the typed data to the external signer, giving
+// up when ctx is done. Timeout, if set, applies on top of any ctx deadline.
+func (s *ExternalSigner) SignTypedDataContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	return s.signTypedData(ctx, domain, types, primaryType, message)
+}
+
+// SignTypedDataFastContext is SignTypedDataFast with a context, checked
+// while encoding large arrays
+func (s *FastSigner) SignTypedDataFastContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	hash, err := NewFastTypedDataEncoder(domain, types, primaryType, message).HashContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return s.signDigest(ctx, TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}, hash)
+}
+
+// RecoverContext is Recover with a context, checked before hashing
+func (sig *Signature) RecoverContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (common.Address, error) {
+	if err := ctx.Err(); err != nil {
+		return common.Address{}, err
+	}
+	return sig.Recover(domain, types, primaryType, message)
+}
+
+// VerifySignatureContext is VerifySignature with a context
+func VerifySignatureContext(
+	ctx context.Context,
+	sig *Signature,
+	expectedSigner common.Address,
+	domain Domain,
+	types map[string][]Type,
+	primaryType string,
+	message Message,
+) (bool, error) {
+	recoveredAddr, err := sig.RecoverContext(ctx, domain, types, primaryType, message)
+	if err != nil {
+		return false, err
+	}
+	return recoveredAddr == expectedSigner, nil
+}
+
+// RecoverSignatureFastContext is RecoverSignatureFast with a context,
+// checked while encoding large arrays
+func RecoverSignatureFastContext(
+	ctx context.Context,
+	sig *Signature,
+	domain Domain,
+	types map[string][]Type,
+	primaryType string,
+	message Message,
+) (common.Address, error) {
+	hash, err := NewFastTypedDataEncoder(domain, types, primaryType, message).HashContext(ctx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return recoverHash(hash, sig.Bytes)
+}
+
+// VerifySignatureFastContext is VerifySignatureFast with a context
+func VerifySignatureFastContext(
+	ctx context.Context,
+	sig *Signature,
+	expectedSigner common.Address,
+	domain Domain,
+	types map[string][]Type,
+	primaryType string,
+	message Message,
+) (bool, error) {
+	recoveredAddr, err := RecoverSignatureFastContext(ctx, sig, domain, types, primaryType, message)
+	if err != nil {
+		return false, err
+	}
+	return recoveredAddr == expectedSigner, nil
+}
@@ -0,0 +1,169 @@
+package eip712
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countdownContext reports cancellation after Err has been called n times
+type countdownContext struct {
+	context.Context
+	n     int
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// contextKeySigner records the context it was called with
+type contextKeySigner struct {
+	derKeySigner
+	seen context.Context
+}
+
+func (k *contextKeySigner) SignHashContext(ctx context.Context, hash []byte) ([]byte, error) {
+	k.seen = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return k.SignHash(hash)
+}
+
+type contextTestKey struct{}
+
+func largeArrayTypedData(n int) (Domain, map[string][]Type, Message) {
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("%d", i)
+	}
+	types := map[string][]Type{
+		"Batch": {{Name: "values", Type: "uint256[]"}},
+	}
+	return createTestDomain("Test", "1", 1), types, Message{"values": values}
+}
+
+func TestSignTypedDataContext(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	sig, err := signer.SignTypedDataContext(context.Background(), domain, types, "Mail", message)
+	require.NoError(t, err)
+
+	valid, err := VerifySignatureContext(context.Background(), sig, signer.Address(), domain, types, "Mail", message)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = signer.SignTypedDataContext(ctx, domain, types, "Mail", message)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = sig.RecoverContext(ctx, domain, types, "Mail", message)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = VerifySignatureContext(ctx, sig, signer.Address(), domain, types, "Mail", message)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFastSignerContextCancelsLargeArrays(t *testing.T) {
+	signer, err := NewFastSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	domain, types, message := largeArrayTypedData(10000)
+
+	sig, err := signer.SignTypedDataFastContext(context.Background(), domain, types, "Batch", message)
+	require.NoError(t, err)
+	valid, err := VerifySignatureFastContext(context.Background(), sig, signer.Address(), domain, types, "Batch", message)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// Cancellation partway through the array stops encoding
+	ctx := &countdownContext{Context: context.Background(), n: 5}
+	_, err = signer.SignTypedDataFastContext(ctx, domain, types, "Batch", message)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 6, ctx.calls)
+
+	ctx = &countdownContext{Context: context.Background(), n: 5}
+	_, err = RecoverSignatureFastContext(ctx, sig, domain, types, "Batch", message)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The encoder can be reused without a context afterwards
+	encoder := NewFastTypedDataEncoder(domain, types, "Batch", message)
+	_, err = encoder.HashContext(&countdownContext{Context: context.Background(), n: 1})
+	assert.ErrorIs(t, err, context.Canceled)
+	hash, err := encoder.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, sig.Hash, fmt.Sprintf("0x%x", hash))
+}
+
+func TestContextReachesKeySigner(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+	ks := &contextKeySigner{derKeySigner: derKeySigner{privateKey: privateKey}}
+
+	signer, err := NewSignerWithKeySigner(ks, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	ctx := context.WithValue(context.Background(), contextTestKey{}, "request-1")
+	_, err = signer.SignTypedDataContext(ctx, domain, types, "Mail", message)
+	require.NoError(t, err)
+	require.NotNil(t, ks.seen)
+	assert.Equal(t, "request-1", ks.seen.Value(contextTestKey{}))
+
+	// Plain SignTypedData still goes through SignHashContext
+	_, err = signer.SignTypedData(domain, types, "Mail", message)
+	require.NoError(t, err)
+	assert.Nil(t, ks.seen.Value(contextTestKey{}))
+}
+
+func TestKMSSignerContextDeadline(t *testing.T) {
+	client := newFakeKMSClient(t)
+	client.delay = time.Second
+
+	kms, err := NewKMSSigner(context.Background(), client, "alias/test")
+	require.NoError(t, err)
+	signer, err := NewSignerWithKeySigner(kms, 1)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = signer.SignTypedDataContext(ctx, createTestDomain("Test", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), client.delay)
+}
+
+func TestExternalSignerContext(t *testing.T) {
+	_, url := newStubClef(t, testPrivateKey1)
+
+	external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+	require.NoError(t, err)
+	defer external.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = external.SignTypedDataContext(ctx, createTestDomain("Test", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"))
+	assert.ErrorIs(t, err, context.Canceled)
+}
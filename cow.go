@@ -0,0 +1,148 @@
+package eip712
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// GPv2SettlementAddress is the CoW Protocol settlement contract, deployed at
+// the same address on every supported chain
+var GPv2SettlementAddress = common.HexToAddress("0x9008D19f58AAbD9eD0D60971565AA8510560ab41")
+
+// CoW Protocol order kinds
+const (
+	CowOrderKindSell = "sell"
+	CowOrderKindBuy  = "buy"
+)
+
+// CoW Protocol token balance sources and destinations
+const (
+	CowBalanceERC20    = "erc20"
+	CowBalanceExternal = "external"
+	CowBalanceInternal = "internal"
+)
+
+// cowOrderTypes is the EIP-712 schema of GPv2Order.Data
+var cowOrderTypes = map[string][]Type{
+	"Order": {
+		{Name: "sellToken", Type: "address"},
+		{Name: "buyToken", Type: "address"},
+		{Name: "receiver", Type: "address"},
+		{Name: "sellAmount", Type: "uint256"},
+		{Name: "buyAmount", Type: "uint256"},
+		{Name: "validTo", Type: "uint32"},
+		{Name: "appData", Type: "bytes32"},
+		{Name: "feeAmount", Type: "uint256"},
+		{Name: "kind", Type: "string"},
+		{Name: "partiallyFillable", Type: "bool"},
+		{Name: "sellTokenBalance", Type: "string"},
+		{Name: "buyTokenBalance", Type: "string"},
+	},
+}
+
+// CowOrder mirrors GPv2Order.Data, a CoW Protocol order. Empty Kind and
+// balance fields default to "sell" and "erc20".
+type CowOrder struct {
+	SellToken         common.Address
+	BuyToken          common.Address
+	Receiver          common.Address
+	SellAmount        *big.Int
+	BuyAmount         *big.Int
+	ValidTo           uint32
+	AppData           [32]byte
+	FeeAmount         *big.Int
+	Kind              string
+	PartiallyFillable bool
+	SellTokenBalance  string
+	BuyTokenBalance   string
+}
+
+// CowDomain returns the EIP-712 domain of the CoW Protocol settlement contract
+// on the given chain
+func CowDomain(chainID *big.Int) Domain {
+	return Domain{
+		Name:              "Gnosis Protocol",
+		Version:           "v2",
+		ChainID:           chainID,
+		VerifyingContract: GPv2SettlementAddress,
+	}
+}
+
+// Message returns the order as a GPv2Order EIP-712 message
+func (o *CowOrder) Message() Message {
+	return Message{
+		"sellToken":         o.SellToken.Hex(),
+		"buyToken":          o.BuyToken.Hex(),
+		"receiver":          o.Receiver.Hex(),
+		"sellAmount":        bigIntString(o.SellAmount),
+		"buyAmount":         bigIntString(o.BuyAmount),
+		"validTo":           fmt.Sprintf("%d", o.ValidTo),
+		"appData":           hexutil.Encode(o.AppData[:]),
+		"feeAmount":         bigIntString(o.FeeAmount),
+		"kind":              stringOrDefault(o.Kind, CowOrderKindSell),
+		"partiallyFillable": o.PartiallyFillable,
+		"sellTokenBalance":  stringOrDefault(o.SellTokenBalance, CowBalanceERC20),
+		"buyTokenBalance":   stringOrDefault(o.BuyTokenBalance, CowBalanceERC20),
+	}
+}
+
+// Hash returns the order digest on the given chain
+func (o *CowOrder) Hash(chainID *big.Int) ([]byte, error) {
+	encoder := NewFastTypedDataEncoder(CowDomain(chainID), cowOrderTypes, "Order", o.Message())
+	return encoder.Hash()
+}
+
+// UID returns the 56-byte order UID the CoW Protocol API and settlement
+// contract use to identify the order: digest || owner || validTo
+func (o *CowOrder) UID(chainID *big.Int, owner common.Address) ([]byte, error) {
+	digest, err := o.Hash(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	uid := make([]byte, 0, 56)
+	uid = append(uid, digest...)
+	uid = append(uid, owner.Bytes()...)
+	return binary.BigEndian.AppendUint32(uid, o.ValidTo), nil
+}
+
+// SignCowOrder signs a CoW Protocol order for the signer's chain using the
+// EIP-712 signing scheme
+//
+// Example:
+//
+//	order := &CowOrder{
+//	    SellToken:  weth,
+//	    BuyToken:   usdc,
+//	    SellAmount: big.NewInt(1e18),
+//	    BuyAmount:  big.NewInt(3000e6),
+//	    ValidTo:    uint32(time.Now().Add(30 * time.Minute).Unix()),
+//	    FeeAmount:  big.NewInt(0),
+//	    Kind:       CowOrderKindSell,
+//	}
+//
+//	sig, err := signer.SignCowOrder(order)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	uid, _ := order.UID(signer.ChainID(), signer.Address())
+func (s *Signer) SignCowOrder(order *CowOrder) (*Signature, error) {
+	return s.SignTypedData(CowDomain(s.chainID), cowOrderTypes, "Order", order.Message())
+}
+
+// VerifyCowOrder verifies that owner signed order on the given chain
+func VerifyCowOrder(sig *Signature, owner common.Address, chainID *big.Int, order *CowOrder) (bool, error) {
+	return VerifySignature(sig, owner, CowDomain(chainID), cowOrderTypes, "Order", order.Message())
+}
+
+// stringOrDefault returns s, or def when s is empty
+func stringOrDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package eip712
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCowOrder() *CowOrder {
+	return &CowOrder{
+		SellToken:  common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), // WETH
+		BuyToken:   common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), // USDC
+		Receiver:   common.HexToAddress(testAddress1),
+		SellAmount: big.NewInt(1000000000000000000),
+		BuyAmount:  big.NewInt(3000000000),
+		ValidTo:    1893456000,
+		FeeAmount:  big.NewInt(0),
+		Kind:       CowOrderKindSell,
+	}
+}
+
+func TestCowOrderTypeHash(t *testing.T) {
+	encoder := NewFastTypedDataEncoder(CowDomain(big.NewInt(1)), cowOrderTypes, "Order", nil)
+	typeHash, err := encoder.typeHash("Order")
+	require.NoError(t, err)
+	// GPv2Order.TYPE_HASH
+	require.Equal(t, "0xd5a25ba2e97094ad7d83dc28a6572da797d6b3e7fc6663bd93efb789fc17e489", hexutil.Encode(typeHash))
+}
+
+func TestSignCowOrder(t *testing.T) {
+	order := createTestCowOrder()
+
+	for _, chainID := range []int64{1, 100, 42161} {
+		signer, err := NewSigner(testPrivateKey1, chainID)
+		require.NoError(t, err)
+
+		sig, err := signer.SignCowOrder(order)
+		require.NoError(t, err)
+		assertSignatureComponents(t, sig)
+
+		// The fast encoder must agree with the signing path
+		digest, err := order.Hash(signer.ChainID())
+		require.NoError(t, err)
+		require.Equal(t, hexutil.Encode(digest), sig.Hash)
+
+		valid, err := VerifyCowOrder(sig, signer.Address(), signer.ChainID(), order)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		// A signature for one chain must not verify on another
+		valid, err = VerifyCowOrder(sig, signer.Address(), big.NewInt(chainID+1), order)
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+}
+
+func TestCowOrderUID(t *testing.T) {
+	order := createTestCowOrder()
+	owner := common.HexToAddress(testAddress2)
+
+	uid, err := order.UID(big.NewInt(1), owner)
+	require.NoError(t, err)
+	require.Len(t, uid, 56)
+
+	digest, err := order.Hash(big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, digest, uid[:32])
+	require.Equal(t, owner.Bytes(), uid[32:52])
+	require.Equal(t, order.ValidTo, binary.BigEndian.Uint32(uid[52:]))
+}
+
+func TestCowOrderDefaults(t *testing.T) {
+	explicit := createTestCowOrder()
+	explicit.SellTokenBalance = CowBalanceERC20
+	explicit.BuyTokenBalance = CowBalanceERC20
+
+	implicit := createTestCowOrder()
+	implicit.Kind = ""
+
+	h1, err := explicit.Hash(big.NewInt(1))
+	require.NoError(t, err)
+	h2, err := implicit.Hash(big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, h1, h2)
+
+	buy := createTestCowOrder()
+	buy.Kind = CowOrderKindBuy
+	h3, err := buy.Hash(big.NewInt(1))
+	require.NoError(t, err)
+	require.NotEqual(t, h1, h3)
+}
@@ -0,0 +1,324 @@
+package eip712
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ChangeKind says how a value differs between two typed data documents
+type ChangeKind string
+
+// Change kinds
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is one difference between two typed data documents. Path is
+// rooted at "domain", "types", "primaryType" or "message", e.g.
+// "message.details.amount", "message.items[2]" or "types.Permit.value".
+// Old and New hold canonical values: checksummed addresses, decimal
+// integers and 0x-prefixed bytes; a struct or array added or removed as a
+// whole is shown by its type.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// String formats the change as a single line
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// TypedDataDiff is the result of Diff. The hashes say what a verifier
+// would see: a changed domain separator means the signature is for another
+// contract or chain, a changed type hash means the contract must accept a
+// different schema.
+type TypedDataDiff struct {
+	Changes []Change `json:"changes"`
+
+	OldDomainSeparator common.Hash `json:"oldDomainSeparator"`
+	NewDomainSeparator common.Hash `json:"newDomainSeparator"`
+	OldTypeHash        common.Hash `json:"oldTypeHash"`
+	NewTypeHash        common.Hash `json:"newTypeHash"`
+	OldHash            common.Hash `json:"oldHash"`
+	NewHash            common.Hash `json:"newHash"`
+}
+
+// Equal reports whether the two documents sign the same hash
+func (d *TypedDataDiff) Equal() bool {
+	return d.OldHash == d.NewHash
+}
+
+// DomainSeparatorChanged reports whether the domain separators differ
+func (d *TypedDataDiff) DomainSeparatorChanged() bool {
+	return d.OldDomainSeparator != d.NewDomainSeparator
+}
+
+// TypeHashChanged reports whether the primary type hashes differ
+func (d *TypedDataDiff) TypeHashChanged() bool {
+	return d.OldTypeHash != d.NewTypeHash
+}
+
+// String formats the diff for a log or support ticket, one change per line
+// followed by the hashes that changed
+func (d *TypedDataDiff) String() string {
+	var sb strings.Builder
+	for _, change := range d.Changes {
+		sb.WriteString(change.String())
+		sb.WriteByte('\n')
+	}
+	if d.DomainSeparatorChanged() {
+		fmt.Fprintf(&sb, "domain separator: %s -> %s\n", d.OldDomainSeparator.Hex(), d.NewDomainSeparator.Hex())
+	}
+	if d.TypeHashChanged() {
+		fmt.Fprintf(&sb, "type hash: %s -> %s\n", d.OldTypeHash.Hex(), d.NewTypeHash.Hex())
+	}
+	if !d.Equal() {
+		fmt.Fprintf(&sb, "hash: %s -> %s\n", d.OldHash.Hex(), d.NewHash.Hex())
+	}
+	return sb.String()
+}
+
+// Diff compares two typed data documents field by field. Domains are
+// compared by their EIP712Domain fields, types by their field lists and
+// messages along their schemas, so an address differing only in case or an
+// integer given as hex rather than decimal is not a change. Both documents
+// must hash; an error names the one that does not.
+//
+// Example:
+//
+//	diff, err := Diff(original, resigned)
+//	if !diff.Equal() {
+//	    fmt.Print(diff)
+//	    // ~ message.details.amount: 1000000 -> 2000000
+//	    // hash: 0x5c1e... -> 0x9a04...
+//	}
+func Diff(a, b TypedData) (*TypedDataDiff, error) {
+	d := &TypedDataDiff{}
+	if err := hashForDiff(a, &d.OldDomainSeparator, &d.OldTypeHash, &d.OldHash); err != nil {
+		return nil, fmt.Errorf("old typed data: %w", err)
+	}
+	if err := hashForDiff(b, &d.NewDomainSeparator, &d.NewTypeHash, &d.NewHash); err != nil {
+		return nil, fmt.Errorf("new typed data: %w", err)
+	}
+
+	noTimestamps := RenderOptions{TimestampFields: []string{}}
+	oldMessage, err := RenderWithOptions(a.Types, a.PrimaryType, a.Message, noTimestamps)
+	if err != nil {
+		return nil, fmt.Errorf("old typed data: %w", err)
+	}
+	newMessage, err := RenderWithOptions(b.Types, b.PrimaryType, b.Message, noTimestamps)
+	if err != nil {
+		return nil, fmt.Errorf("new typed data: %w", err)
+	}
+
+	d.diffDomain(a, b)
+	if a.PrimaryType != b.PrimaryType {
+		d.add("primaryType", ChangeModified, a.PrimaryType, b.PrimaryType)
+	}
+	d.diffTypes(a.Types, b.Types)
+	d.diffRendered("message", oldMessage, newMessage)
+	return d, nil
+}
+
+// hashForDiff computes the domain separator, primary type hash and hash of
+// data
+func hashForDiff(data TypedData, domainSeparator, typeHash, hash *common.Hash) error {
+	encoder := NewFastTypedDataEncoder(data.Domain, data.Types, data.PrimaryType, data.Message)
+	digest, err := encoder.Hash()
+	if err != nil {
+		return err
+	}
+	separator, err := encoder.DomainSeparator()
+	if err != nil {
+		return err
+	}
+	primary, err := encoder.typeHash(data.PrimaryType)
+	if err != nil {
+		return err
+	}
+	*hash = common.BytesToHash(digest)
+	*domainSeparator = common.BytesToHash(separator)
+	*typeHash = common.BytesToHash(primary)
+	return nil
+}
+
+func (d *TypedDataDiff) add(path string, kind ChangeKind, old, new string) {
+	d.Changes = append(d.Changes, Change{Path: path, Kind: kind, Old: old, New: new})
+}
+
+// diffValue records the change of one scalar, which is absent when its
+// present flag is false
+func (d *TypedDataDiff) diffValue(path string, old string, hasOld bool, new string, hasNew bool) {
+	switch {
+	case hasOld && !hasNew:
+		d.add(path, ChangeRemoved, old, "")
+	case !hasOld && hasNew:
+		d.add(path, ChangeAdded, "", new)
+	case hasOld && hasNew && old != new:
+		d.add(path, ChangeModified, old, new)
+	}
+}
+
+// diffDomain compares the domain fields each document hashes, so a field
+// set but left out of an explicit EIP712Domain type is not a change
+func (d *TypedDataDiff) diffDomain(old, new TypedData) {
+	a, b := old.Domain, new.Domain
+	oldFields, newFields := a.hashedFields(old.Types), b.hashedFields(new.Types)
+	has := func(field DomainFields) (bool, bool) {
+		return oldFields.Has(field), newFields.Has(field)
+	}
+
+	hasOld, hasNew := has(DomainFieldName)
+	d.diffValue("domain.name", a.Name, hasOld, b.Name, hasNew)
+	hasOld, hasNew = has(DomainFieldVersion)
+	d.diffValue("domain.version", a.Version, hasOld, b.Version, hasNew)
+	hasOld, hasNew = has(DomainFieldChainID)
+	d.diffValue("domain.chainId", domainChainID(a).String(), hasOld, domainChainID(b).String(), hasNew)
+	hasOld, hasNew = has(DomainFieldVerifyingContract)
+	d.diffValue("domain.verifyingContract", a.VerifyingContract.Hex(), hasOld, b.VerifyingContract.Hex(), hasNew)
+	hasOld, hasNew = has(DomainFieldSalt)
+	d.diffValue("domain.salt", hexutil.Encode(a.Salt[:]), hasOld, hexutil.Encode(b.Salt[:]), hasNew)
+}
+
+// diffTypes compares type definitions field by field. A type whose fields
+// are only reordered is reported as a whole, since the order is part of its
+// encoding.
+func (d *TypedDataDiff) diffTypes(a, b map[string][]Type) {
+	names := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		path := "types." + name
+		oldFields, hasOld := a[name]
+		newFields, hasNew := b[name]
+		if !hasOld || !hasNew {
+			d.diffValue(path, typeSignature(name, oldFields), hasOld, typeSignature(name, newFields), hasNew)
+			continue
+		}
+
+		before := len(d.Changes)
+		oldTypes, newTypes := fieldTypes(oldFields), fieldTypes(newFields)
+		for _, field := range oldFields {
+			newType, ok := newTypes[field.Name]
+			d.diffValue(path+"."+field.Name, field.Type, true, newType, ok)
+		}
+		for _, field := range newFields {
+			if _, ok := oldTypes[field.Name]; !ok {
+				d.add(path+"."+field.Name, ChangeAdded, "", field.Type)
+			}
+		}
+
+		oldSignature, newSignature := typeSignature(name, oldFields), typeSignature(name, newFields)
+		if len(d.Changes) == before && oldSignature != newSignature {
+			d.add(path, ChangeModified, oldSignature, newSignature)
+		}
+	}
+}
+
+func fieldTypes(fields []Type) map[string]string {
+	types := make(map[string]string, len(fields))
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+	return types
+}
+
+// typeSignature formats one type definition as in encodeType, without its
+// dependencies
+func typeSignature(name string, fields []Type) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.Type + " " + field.Name
+	}
+	return name + "(" + strings.Join(parts, ",") + ")"
+}
+
+// diffRendered compares two rendered values. Struct fields are matched by
+// name and array elements by index.
+func (d *TypedDataDiff) diffRendered(path string, a, b *RenderedField) {
+	switch {
+	case a == nil:
+		d.add(path, ChangeAdded, "", renderedValue(b))
+		return
+	case b == nil:
+		d.add(path, ChangeRemoved, renderedValue(a), "")
+		return
+	case (a.Fields == nil) != (b.Fields == nil):
+		d.add(path, ChangeModified, renderedValue(a), renderedValue(b))
+		return
+	case a.Fields == nil:
+		d.diffValue(path, renderedValue(a), true, renderedValue(b), true)
+		return
+	}
+
+	if isArrayType(a.Type) && isArrayType(b.Type) {
+		for i := 0; i < len(a.Fields) || i < len(b.Fields); i++ {
+			var oldElement, newElement *RenderedField
+			if i < len(a.Fields) {
+				oldElement = a.Fields[i]
+			}
+			if i < len(b.Fields) {
+				newElement = b.Fields[i]
+			}
+			d.diffRendered(fmt.Sprintf("%s[%d]", path, i), oldElement, newElement)
+		}
+		return
+	}
+	if isArrayType(a.Type) != isArrayType(b.Type) {
+		d.add(path, ChangeModified, a.Type, b.Type)
+		return
+	}
+
+	newFields := make(map[string]*RenderedField, len(b.Fields))
+	for _, field := range b.Fields {
+		newFields[field.Name] = field
+	}
+	for _, field := range a.Fields {
+		d.diffRendered(path+"."+field.Name, field, newFields[field.Name])
+		delete(newFields, field.Name)
+	}
+	for _, field := range b.Fields {
+		if _, ok := newFields[field.Name]; ok {
+			d.diffRendered(path+"."+field.Name, nil, field)
+		}
+	}
+}
+
+// renderedValue is the canonical value of a leaf, or the type of a struct
+// or array
+func renderedValue(f *RenderedField) string {
+	switch {
+	case f.Fields != nil:
+		return f.Type
+	case f.Raw != "":
+		return f.Raw
+	default:
+		return f.Value
+	}
+}
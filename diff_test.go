@@ -0,0 +1,178 @@
+package eip712
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	a := createTestPermit(testOwner, testSpender, big.NewInt(1000000), big.NewInt(0))
+	b := createTestPermit(testOwner, testSpender, big.NewInt(1000000), big.NewInt(0))
+
+	// Same values in other representations are not changes
+	b.Message["owner"] = strings.ToLower(testAddress1)
+	b.Message["value"] = "0xf4240"
+
+	diff, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+	assert.True(t, diff.Equal())
+	assert.Empty(t, diff.String())
+}
+
+func TestDiffMessage(t *testing.T) {
+	diff, err := Diff(createTestPermit(testOwner, testSpender, big.NewInt(1000000), big.NewInt(0)), createTestPermit(testOwner, testSpender, big.NewInt(2000000), big.NewInt(0)))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Change{
+		{Path: "message.value", Kind: ChangeModified, Old: "1000000", New: "2000000"},
+	}, diff.Changes)
+	assert.False(t, diff.Equal())
+	assert.False(t, diff.DomainSeparatorChanged())
+	assert.False(t, diff.TypeHashChanged())
+	assert.Equal(t, "~ message.value: 1000000 -> 2000000\n"+
+		"hash: "+diff.OldHash.Hex()+" -> "+diff.NewHash.Hex()+"\n", diff.String())
+}
+
+func TestDiffDomain(t *testing.T) {
+	a := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+	b := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+	b.Domain.ChainID = big.NewInt(137)
+	b.Domain.Salt = [32]byte{1}
+
+	diff, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "domain.chainId", Kind: ChangeModified, Old: "1", New: "137"},
+		{Path: "domain.salt", Kind: ChangeAdded, New: "0x0100000000000000000000000000000000000000000000000000000000000000"},
+	}, diff.Changes)
+	assert.True(t, diff.DomainSeparatorChanged())
+	assert.False(t, diff.TypeHashChanged())
+}
+
+func TestDiffDomainExplicitType(t *testing.T) {
+	explicit := func() TypedData {
+		data := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+		data.Types["EIP712Domain"] = []Type{
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "verifyingContract", Type: "address"},
+		}
+		return data
+	}
+	a := explicit()
+	b := explicit()
+
+	// Values the explicit type leaves out are not hashed, so not changes
+	b.Domain.ChainID = big.NewInt(137)
+	b.Domain.Salt = [32]byte{1}
+	diff, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+	assert.False(t, diff.DomainSeparatorChanged())
+
+	b.Domain.Version = "3"
+	diff, err = Diff(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "domain.version", Kind: ChangeModified, Old: "2", New: "3"},
+	}, diff.Changes)
+	assert.True(t, diff.DomainSeparatorChanged())
+}
+
+func TestDiffTypes(t *testing.T) {
+	a := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+	b := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+	b.Types = map[string][]Type{"Permit": {
+		{Name: "owner", Type: "address"},
+		{Name: "spender", Type: "address"},
+		{Name: "value", Type: "uint128"},
+		{Name: "deadline", Type: "uint256"},
+		{Name: "memo", Type: "string"},
+	}}
+	delete(b.Message, "nonce")
+	b.Message["memo"] = "hi"
+
+	diff, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "types.Permit.value", Kind: ChangeModified, Old: "uint256", New: "uint128"},
+		{Path: "types.Permit.nonce", Kind: ChangeRemoved, Old: "uint256"},
+		{Path: "types.Permit.memo", Kind: ChangeAdded, New: "string"},
+		{Path: "message.nonce", Kind: ChangeRemoved, Old: "0"},
+		{Path: "message.memo", Kind: ChangeAdded, New: "hi"},
+	}, diff.Changes)
+	assert.True(t, diff.TypeHashChanged())
+	assert.False(t, diff.DomainSeparatorChanged())
+
+	t.Run("reordered", func(t *testing.T) {
+		b := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+		fields := append([]Type(nil), b.Types["Permit"]...)
+		fields[0], fields[1] = fields[1], fields[0]
+		b.Types = map[string][]Type{"Permit": fields}
+
+		diff, err := Diff(a, b)
+		require.NoError(t, err)
+		require.Len(t, diff.Changes, 1)
+		assert.Equal(t, "types.Permit", diff.Changes[0].Path)
+		assert.Equal(t, "Permit(address spender,address owner,uint256 value,uint256 nonce,uint256 deadline)", diff.Changes[0].New)
+		assert.True(t, diff.TypeHashChanged())
+	})
+}
+
+func TestDiffNested(t *testing.T) {
+	types := map[string][]Type{
+		"Order": {
+			{Name: "maker", Type: "Person"},
+			{Name: "amounts", Type: "uint256[]"},
+		},
+		"Person": {
+			{Name: "name", Type: "string"},
+			{Name: "wallet", Type: "address"},
+		},
+	}
+	order := func(wallet string, amounts ...interface{}) TypedData {
+		return TypedData{
+			Domain:      createTestDomain("Exchange", "1", 1),
+			Types:       types,
+			PrimaryType: "Order",
+			Message: Message{
+				"maker":   map[string]interface{}{"name": "Alice", "wallet": wallet},
+				"amounts": amounts,
+			},
+		}
+	}
+
+	diff, err := Diff(order(testAddress1, "1", "2"), order(testAddress2, "1", "3", "4"))
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "message.maker.wallet", Kind: ChangeModified, Old: common.HexToAddress(testAddress1).Hex(), New: common.HexToAddress(testAddress2).Hex()},
+		{Path: "message.amounts[1]", Kind: ChangeModified, Old: "2", New: "3"},
+		{Path: "message.amounts[2]", Kind: ChangeAdded, New: "4"},
+	}, diff.Changes)
+
+	t.Run("primary type", func(t *testing.T) {
+		b := order(testAddress1, "1")
+		b.PrimaryType = "Person"
+		b.Message = Message{"name": "Alice", "wallet": testAddress1}
+
+		diff, err := Diff(order(testAddress1, "1"), b)
+		require.NoError(t, err)
+		assert.Equal(t, Change{Path: "primaryType", Kind: ChangeModified, Old: "Order", New: "Person"}, diff.Changes[0])
+		assert.True(t, diff.TypeHashChanged())
+	})
+}
+
+func TestDiffInvalid(t *testing.T) {
+	b := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+	b.Message = Message{"owner": testAddress1}
+
+	_, err := Diff(createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0)), b)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "new typed data")
+}
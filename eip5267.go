@@ -0,0 +1,168 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrDomainMismatch is returned when a Domain does not match the domain a
+// contract declares
+var ErrDomainMismatch = errors.New("domain does not match contract")
+
+// eip712DomainABI is the EIP-5267 eip712Domain() view function
+const eip712DomainABI = `[{"name":"eip712Domain","type":"function","stateMutability":"view","inputs":[],"outputs":[
+	{"name":"fields","type":"bytes1"},
+	{"name":"name","type":"string"},
+	{"name":"version","type":"string"},
+	{"name":"chainId","type":"uint256"},
+	{"name":"verifyingContract","type":"address"},
+	{"name":"salt","type":"bytes32"},
+	{"name":"extensions","type":"uint256[]"}
+]}]`
+
+var eip712DomainMethod = func() abi.Method {
+	parsed, err := abi.JSON(strings.NewReader(eip712DomainABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed.Methods["eip712Domain"]
+}()
+
+// ContractCaller performs read-only contract calls. *ethclient.Client
+// satisfies it, and tests can substitute a fake.
+type ContractCaller interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// DiscoveredDomain is a domain read from a contract's eip712Domain()
+type DiscoveredDomain struct {
+	// Domain holds the declared field values, with Fields set so that it
+	// hashes exactly as the contract does; undeclared fields are zero
+	Domain Domain
+	// Fields is the set of fields the contract includes in its EIP712Domain
+	Fields DomainFields
+	// Extensions lists EIPs defining additional domain fields, if any
+	Extensions []*big.Int
+}
+
+// DiscoverDomain reads the EIP-712 domain of contract through its EIP-5267
+// eip712Domain() function
+//
+// Example:
+//
+//	client, _ := ethclient.Dial(rpcURL)
+//	discovered, err := DiscoverDomain(ctx, client, tokenContract)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	sig, err := signer.SignTypedData(discovered.Domain, types, "Permit", message)
+func DiscoverDomain(ctx context.Context, caller ContractCaller, contract common.Address) (*DiscoveredDomain, error) {
+	output, err := caller.CallContract(ctx, ethereum.CallMsg{
+		To:   &contract,
+		Data: eip712DomainMethod.ID,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call eip712Domain: %w", err)
+	}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("contract %s does not implement eip712Domain", contract.Hex())
+	}
+
+	values, err := eip712DomainMethod.Outputs.Unpack(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode eip712Domain result: %w", err)
+	}
+
+	fields := DomainFields(values[0].([1]byte)[0])
+	if fields&^allDomainFields != 0 {
+		return nil, fmt.Errorf("unsupported domain fields bitmap: %#02x", uint8(fields))
+	}
+
+	discovered := &DiscoveredDomain{
+		Domain:     Domain{Fields: fields},
+		Fields:     fields,
+		Extensions: values[6].([]*big.Int),
+	}
+	if fields.Has(DomainFieldName) {
+		discovered.Domain.Name = values[1].(string)
+	}
+	if fields.Has(DomainFieldVersion) {
+		discovered.Domain.Version = values[2].(string)
+	}
+	if fields.Has(DomainFieldChainID) {
+		discovered.Domain.ChainID = values[3].(*big.Int)
+	}
+	if fields.Has(DomainFieldVerifyingContract) {
+		discovered.Domain.VerifyingContract = values[4].(common.Address)
+	}
+	if fields.Has(DomainFieldSalt) {
+		discovered.Domain.Salt = values[5].([32]byte)
+	}
+
+	return discovered, nil
+}
+
+// Types returns the EIP712Domain type definition the contract uses
+func (d *DiscoveredDomain) Types() []Type {
+	return d.Fields.Types()
+}
+
+// Validate checks that signing with domain (and the EIP712Domain type given
+// by its FieldSet) produces the domain separator the contract computes. The
+// returned error wraps ErrDomainMismatch and lists every differing field.
+func (d *DiscoveredDomain) Validate(domain Domain) error {
+	var problems []string
+
+	if d.Fields.Has(DomainFieldName) && domain.Name != d.Domain.Name {
+		problems = append(problems, fmt.Sprintf("name is %q, contract declares %q", domain.Name, d.Domain.Name))
+	}
+	if d.Fields.Has(DomainFieldVersion) && domain.Version != d.Domain.Version {
+		problems = append(problems, fmt.Sprintf("version is %q, contract declares %q", domain.Version, d.Domain.Version))
+	}
+	if d.Fields.Has(DomainFieldChainID) && (domain.ChainID == nil || domain.ChainID.Cmp(d.Domain.ChainID) != 0) {
+		problems = append(problems, fmt.Sprintf("chainId is %v, contract declares %v", domain.ChainID, d.Domain.ChainID))
+	}
+	if d.Fields.Has(DomainFieldVerifyingContract) && domain.VerifyingContract != d.Domain.VerifyingContract {
+		problems = append(problems, fmt.Sprintf("verifyingContract is %s, contract declares %s", domain.VerifyingContract.Hex(), d.Domain.VerifyingContract.Hex()))
+	}
+	if d.Fields.Has(DomainFieldSalt) && domain.Salt != d.Domain.Salt {
+		problems = append(problems, fmt.Sprintf("salt is %x, contract declares %x", domain.Salt, d.Domain.Salt))
+	}
+
+	// The derived EIP712Domain type must list exactly the declared fields
+	if derived := domain.FieldSet(); derived != d.Fields {
+		if missing := d.Fields &^ derived; missing != 0 {
+			problems = append(problems, fmt.Sprintf("missing fields %s", domainFieldNames(missing)))
+		}
+		if extra := derived &^ d.Fields; extra != 0 {
+			problems = append(problems, fmt.Sprintf("contract does not declare %s", domainFieldNames(extra)))
+		}
+	}
+
+	if len(d.Extensions) > 0 {
+		problems = append(problems, fmt.Sprintf("contract declares unsupported extensions %v", d.Extensions))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrDomainMismatch, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// domainFieldNames lists the field names in a bitmap
+func domainFieldNames(fields DomainFields) string {
+	types := fields.Types()
+	names := make([]string, len(types))
+	for i, field := range types {
+		names[i] = field.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,163 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContractCaller answers eip712Domain() calls with a fixed result
+type fakeContractCaller struct {
+	output []byte
+	err    error
+	calls  []ethereum.CallMsg
+}
+
+func (f *fakeContractCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls = append(f.calls, call)
+	return f.output, f.err
+}
+
+func newFakeDomainCaller(t *testing.T, fields DomainFields, name, version string, chainID int64, contract common.Address, salt [32]byte) *fakeContractCaller {
+	t.Helper()
+	output, err := eip712DomainMethod.Outputs.Pack([1]byte{byte(fields)}, name, version, big.NewInt(chainID), contract, salt, []*big.Int{})
+	require.NoError(t, err)
+	return &fakeContractCaller{output: output}
+}
+
+func TestDiscoverDomain(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	fields := DomainFieldName | DomainFieldVersion | DomainFieldChainID | DomainFieldVerifyingContract
+	caller := newFakeDomainCaller(t, fields, "USD Coin", "2", 1, usdc, [32]byte{})
+
+	discovered, err := DiscoverDomain(context.Background(), caller, usdc)
+	require.NoError(t, err)
+
+	require.Len(t, caller.calls, 1)
+	require.Equal(t, usdc, *caller.calls[0].To)
+	require.Equal(t, []byte{0x84, 0xb0, 0x19, 0x6e}, caller.calls[0].Data)
+
+	require.Equal(t, fields, discovered.Fields)
+	require.Equal(t, "USD Coin", discovered.Domain.Name)
+	require.Equal(t, "2", discovered.Domain.Version)
+	require.Equal(t, int64(1), discovered.Domain.ChainID.Int64())
+	require.Equal(t, usdc, discovered.Domain.VerifyingContract)
+	require.Equal(t, []Type{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}, discovered.Types())
+
+	// The discovered domain validates against itself and a hand-built equivalent
+	require.NoError(t, discovered.Validate(discovered.Domain))
+	require.NoError(t, discovered.Validate(createTestDomainWithContract("USD Coin", "2", 1, usdc.Hex())))
+
+	// Signing with the discovered domain matches signing with its explicit type
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	message := createPermitMessage(signer.Address().Hex(), testAddress2, big.NewInt(1), big.NewInt(0), big.NewInt(1893456000))
+	sig1, err := signer.SignTypedData(discovered.Domain, createPermitTypes(), "Permit", message)
+	require.NoError(t, err)
+	types := createPermitTypes()
+	types["EIP712Domain"] = discovered.Types()
+	sig2, err := signer.SignTypedData(discovered.Domain, types, "Permit", message)
+	require.NoError(t, err)
+	compareSignatures(t, sig1, sig2)
+}
+
+func TestValidateDiscoveredDomain(t *testing.T) {
+	token := common.HexToAddress(testAddress1)
+	fields := DomainFieldName | DomainFieldVersion | DomainFieldChainID | DomainFieldVerifyingContract
+	caller := newFakeDomainCaller(t, fields, "My Token", "2", 1, token, [32]byte{})
+
+	discovered, err := DiscoverDomain(context.Background(), caller, token)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		domain  Domain
+		wantMsg string
+	}{
+		{
+			name:    "Wrong version",
+			domain:  createTestDomainWithContract("My Token", "1", 1, token.Hex()),
+			wantMsg: `version is "1", contract declares "2"`,
+		},
+		{
+			name:    "Wrong chain",
+			domain:  createTestDomainWithContract("My Token", "2", 137, token.Hex()),
+			wantMsg: "chainId is 137, contract declares 1",
+		},
+		{
+			name:    "Missing chain",
+			domain:  Domain{Name: "My Token", Version: "2", VerifyingContract: token},
+			wantMsg: "missing fields chainId",
+		},
+		{
+			name:    "Undeclared salt",
+			domain:  createTestDomainWithSalt("My Token", "2", 1, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+			wantMsg: "contract does not declare salt",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := discovered.Validate(tc.domain)
+			require.ErrorIs(t, err, ErrDomainMismatch)
+			require.Contains(t, err.Error(), tc.wantMsg)
+		})
+	}
+}
+
+func TestDiscoverDomainWithoutVersion(t *testing.T) {
+	contract := common.HexToAddress(testAddress2)
+	fields := DomainFieldName | DomainFieldChainID | DomainFieldVerifyingContract
+	caller := newFakeDomainCaller(t, fields, "Permit2", "ignored", 10, contract, [32]byte{})
+
+	discovered, err := DiscoverDomain(context.Background(), caller, contract)
+	require.NoError(t, err)
+	require.Empty(t, discovered.Domain.Version)
+	require.Equal(t, Permit2Domain(big.NewInt(10)).FieldSet().Types(), discovered.Types())
+
+	// The discovered domain carries its field selection
+	require.NoError(t, discovered.Validate(discovered.Domain))
+
+	// A domain without explicit fields always carries a version
+	plain := discovered.Domain
+	plain.Fields = 0
+	err = discovered.Validate(plain)
+	require.ErrorIs(t, err, ErrDomainMismatch)
+	require.Contains(t, err.Error(), "contract does not declare version")
+}
+
+func TestDiscoverDomainErrors(t *testing.T) {
+	contract := common.HexToAddress(testAddress1)
+
+	t.Run("Call error", func(t *testing.T) {
+		callErr := errors.New("execution reverted")
+		_, err := DiscoverDomain(context.Background(), &fakeContractCaller{err: callErr}, contract)
+		require.ErrorIs(t, err, callErr)
+	})
+
+	t.Run("No code", func(t *testing.T) {
+		_, err := DiscoverDomain(context.Background(), &fakeContractCaller{}, contract)
+		require.Error(t, err)
+	})
+
+	t.Run("Malformed result", func(t *testing.T) {
+		_, err := DiscoverDomain(context.Background(), &fakeContractCaller{output: []byte{1, 2, 3}}, contract)
+		require.Error(t, err)
+	})
+
+	t.Run("Reserved field bits", func(t *testing.T) {
+		caller := newFakeDomainCaller(t, 0x20|DomainFieldName, "X", "", 1, contract, [32]byte{})
+		_, err := DiscoverDomain(context.Background(), caller, contract)
+		require.Error(t, err)
+	})
+}
@@ -3,38 +3,40 @@
 // Thread Safety: While the Signer type itself is safe for concurrent use, the
 // underlying go-ethereum library has race conditions when processing shared
 // *big.Int values. To avoid races:
-//   - Create separate Signer instances for concurrent operations
-//   - Use string values instead of *big.Int in messages
-//   - Clone *big.Int values before sharing across goroutines
-//   - See RACE_CONDITIONS.md for detailed information
+//   - Use a SignerPool, which copies its inputs, for concurrent operations
+//   - Or use string values instead of *big.Int in messages
+//   - Or clone *big.Int values before sharing across goroutines
 //
 // Example of safe concurrent usage:
 //
-//	// SAFE: Each goroutine has its own signer
-//	go func() {
-//	    signer, _ := NewSigner(privateKey, 1)
-//	    signer.SignTypedData(...)
-//	}()
+//	// SAFE: The pool copies each message before encoding it
+//	pool := NewSignerPool(signer, 0)
+//	go func() { pool.SignTypedData(domain, types, "Mail", message) }()
+//	go func() { pool.SignTypedData(domain, types, "Mail", message) }()
 //
-//	// UNSAFE: Shared signer across goroutines
-//	signer, _ := NewSigner(privateKey, 1)
-//	go func() { signer.SignTypedData(...) }()  // Race condition!
-//	go func() { signer.SignTypedData(...) }()  // Race condition!
+//	// UNSAFE: The same *big.Int values signed from two goroutines
+//	message := Message{"amount": big.NewInt(100)}
+//	go func() { signer.SignTypedData(domain, types, "Transfer", message) }()  // Race condition!
+//	go func() { signer.SignTypedData(domain, types, "Transfer", message) }()  // Race condition!
 //
 // Security Notes:
-//   - Private keys are stored in memory and not zeroed after use
-//   - This package does not implement replay attack protection - applications
-//     should implement their own nonce management
+//   - Private keys stay in memory until the signer is closed; call Close when
+//     a signer is no longer needed to zero the key
+//   - Replay protection is left to the application: allocate nonces with a
+//     NonceManager when signing and reject reused messages with a
+//     ReplayGuard when verifying
 //   - Always validate input data before signing
 package eip712
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -47,9 +49,13 @@ import (
 
 // Signer provides a simple interface for EIP-712 signing
 type Signer struct {
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	chainID    *big.Int
+	key          *signingKey
+	address      common.Address
+	chainID      *big.Int
+	expiry       *ExpiryPolicy
+	policy       Policy
+	audit        AuditSink
+	enforceChain bool
 }
 
 // NewSigner creates a new EIP-712 signer from a private key
@@ -62,10 +68,7 @@ type Signer struct {
 //	}
 //	fmt.Println(signer.Address()) // 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266
 func NewSigner(privateKeyHex string, chainID int64) (*Signer, error) {
-	// Remove 0x prefix if present
-	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
-	
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	privateKey, err := privateKeyFromHex(privateKeyHex)
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %w", err)
 	}
@@ -79,9 +82,9 @@ func NewSigner(privateKeyHex string, chainID int64) (*Signer, error) {
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 	
 	return &Signer{
-		privateKey: privateKey,
-		address:    address,
-		chainID:    big.NewInt(chainID),
+		key:     newSigningKey(privateKey),
+		address: address,
+		chainID: big.NewInt(chainID),
 	}, nil
 }
 
@@ -93,9 +96,9 @@ func NewSignerFromKeystore(keystoreJSON []byte, password string, chainID int64)
 	}
 	
 	return &Signer{
-		privateKey: key.PrivateKey,
-		address:    key.Address,
-		chainID:    big.NewInt(chainID),
+		key:     newSigningKey(key.PrivateKey),
+		address: key.Address,
+		chainID: big.NewInt(chainID),
 	}, nil
 }
 
@@ -109,18 +112,123 @@ func (s *Signer) ChainID() *big.Int {
 	return s.chainID
 }
 
+// Close zeroes the signer's private key, or closes its KeySigner if that is
+// an io.Closer. Signing afterwards returns ErrSignerClosed; Address and
+// ChainID keep working. Close waits for signatures in progress and may be
+// called more than once.
+func (s *Signer) Close() error {
+	return s.key.destroy()
+}
+
 // Domain represents the EIP-712 domain separator
+//
+// By default the EIP712Domain type includes name and version plus every
+// optional field with a non-zero value. Set Fields to select the fields
+// explicitly, e.g. to include a zero chainId or verifyingContract, or to omit
+// name or version. To also control field order, pass an explicit
+// "EIP712Domain" entry in the types map.
+//
+// A Domain encodes to JSON as the domain object of a typed data document,
+// holding exactly the selected fields.
 type Domain struct {
-	Name              string         `json:"name"`
-	Version           string         `json:"version"`
-	ChainID           *big.Int       `json:"chainId,omitempty"`
-	VerifyingContract common.Address `json:"verifyingContract,omitempty"`
-	Salt              [32]byte       `json:"salt,omitempty"`
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract common.Address
+	Salt              [32]byte
+	Fields            DomainFields
+}
+
+// FieldSet returns the fields included in the domain's EIP712Domain type:
+// Fields if set, otherwise name, version and the non-zero optional fields
+func (d Domain) FieldSet() DomainFields {
+	if d.Fields != 0 {
+		return d.Fields
+	}
+	
+	fields := DomainFieldName | DomainFieldVersion
+	if d.ChainID != nil {
+		fields |= DomainFieldChainID
+	}
+	if d.VerifyingContract != (common.Address{}) {
+		fields |= DomainFieldVerifyingContract
+	}
+	if d.Salt != [32]byte{} {
+		fields |= DomainFieldSalt
+	}
+	return fields
+}
+
+// hashedFields returns the fields of d that are hashed along with types:
+// those named by an explicit "EIP712Domain" type, otherwise FieldSet
+func (d Domain) hashedFields(types map[string][]Type) DomainFields {
+	domainTypes, ok := types["EIP712Domain"]
+	if !ok {
+		return d.FieldSet()
+	}
+	
+	var fields DomainFields
+	for _, field := range domainTypes {
+		if known, ok := domainFieldBits[field.Name]; ok {
+			fields |= known.bit
+		}
+	}
+	return fields
+}
+
+// DomainFields is a bitmap of the fields present in an EIP712Domain, using
+// the EIP-5267 bit assignments
+type DomainFields uint8
+
+// Domain field bits, in EIP712Domain field order
+const (
+	DomainFieldName DomainFields = 1 << iota
+	DomainFieldVersion
+	DomainFieldChainID
+	DomainFieldVerifyingContract
+	DomainFieldSalt
+
+	allDomainFields = DomainFieldName | DomainFieldVersion | DomainFieldChainID | DomainFieldVerifyingContract | DomainFieldSalt
+)
+
+// Has reports whether all of the given fields are present
+func (f DomainFields) Has(fields DomainFields) bool {
+	return f&fields == fields
+}
+
+// Types returns the EIP712Domain type definition for the present fields
+func (f DomainFields) Types() []Type {
+	types := make([]Type, 0, 5)
+	if f.Has(DomainFieldName) {
+		types = append(types, Type{Name: "name", Type: "string"})
+	}
+	if f.Has(DomainFieldVersion) {
+		types = append(types, Type{Name: "version", Type: "string"})
+	}
+	if f.Has(DomainFieldChainID) {
+		types = append(types, Type{Name: "chainId", Type: "uint256"})
+	}
+	if f.Has(DomainFieldVerifyingContract) {
+		types = append(types, Type{Name: "verifyingContract", Type: "address"})
+	}
+	if f.Has(DomainFieldSalt) {
+		types = append(types, Type{Name: "salt", Type: "bytes32"})
+	}
+	return types
 }
 
 // Message represents a simple wrapper for EIP-712 messages
 type Message map[string]interface{}
 
+// TypedData bundles the inputs of one EIP-712 signature. It encodes to and
+// from the JSON document taken by eth_signTypedData_v4.
+type TypedData struct {
+	Domain      Domain
+	Types       map[string][]Type
+	PrimaryType string
+	Message     Message
+}
+
 // SignTypedData signs an EIP-712 typed data message
 //
 // Example:
@@ -161,56 +269,7 @@ type Message map[string]interface{}
 //	}
 //	fmt.Printf("Signature: %s\n", sig.Bytes)
 func (s *Signer) SignTypedData(domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
-	// Validate for cyclic structures
-	if err := validateNoCycles(types); err != nil {
-		return nil, err
-	}
-	// Convert to apitypes format
-	typedData := apitypes.TypedData{
-		Types:       make(apitypes.Types),
-		PrimaryType: primaryType,
-		Domain:      s.domainToAPITypes(domain),
-		Message:     apitypes.TypedDataMessage(message),
-	}
-	
-	// Convert types
-	for typeName, fields := range types {
-		typedData.Types[typeName] = make([]apitypes.Type, len(fields))
-		for i, field := range fields {
-			typedData.Types[typeName][i] = apitypes.Type{
-				Name: field.Name,
-				Type: field.Type,
-			}
-		}
-	}
-	
-	// Add EIP712Domain type if not present
-	if _, ok := typedData.Types["EIP712Domain"]; !ok {
-		typedData.Types["EIP712Domain"] = s.buildDomainTypes(domain)
-	}
-	
-	// Hash the typed data
-	hash, _, err := apitypes.TypedDataAndHash(typedData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash typed data: %w", err)
-	}
-	
-	// Sign the hash
-	signature, err := crypto.Sign(hash, s.privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
-	}
-	
-	// Transform V from 0/1 to 27/28 per Ethereum convention
-	signature[64] += 27
-	
-	return &Signature{
-		R:     hexutil.Encode(signature[:32]),
-		S:     hexutil.Encode(signature[32:64]),
-		V:     uint8(signature[64]),
-		Hash:  hexutil.Encode(hash),
-		Bytes: hexutil.Encode(signature),
-	}, nil
+	return s.SignTypedDataContext(context.Background(), domain, types, primaryType, message)
 }
 
 // Type represents an EIP-712 type field
@@ -243,38 +302,47 @@ type Signature struct {
 //	    fmt.Println("Signature is valid!")
 //	}
 func (sig *Signature) Recover(domain Domain, types map[string][]Type, primaryType string, message Message) (common.Address, error) {
-	// Recreate the typed data for hashing
-	typedData := apitypes.TypedData{
-		Types:       make(apitypes.Types),
-		PrimaryType: primaryType,
-		Domain:      domainToAPITypesStatic(domain),
-		Message:     apitypes.TypedDataMessage(message),
+	// Recreate the typed data hash
+	hash, err := typedDataHash(domain, types, primaryType, message, buildDomainTypesStatic(domain))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to hash typed data: %w", err)
 	}
 	
-	// Convert types
-	for typeName, fields := range types {
-		typedData.Types[typeName] = make([]apitypes.Type, len(fields))
-		for i, field := range fields {
-			typedData.Types[typeName][i] = apitypes.Type{
-				Name: field.Name,
-				Type: field.Type,
-			}
-		}
+	return recoverHash(hash, sig.Bytes)
+}
+
+// Helper functions
+
+// signHash signs a 32-byte digest and packages the result as a Signature
+func signHash(hash []byte, privateKey *ecdsa.PrivateKey) (*Signature, error) {
+	signature, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
 	}
 	
-	// Add EIP712Domain type if not present
-	if _, ok := typedData.Types["EIP712Domain"]; !ok {
-		typedData.Types["EIP712Domain"] = buildDomainTypesStatic(domain)
-	}
+	// Transform V from 0/1 to 27/28 per Ethereum convention
+	signature[64] += 27
 	
-	// Hash the typed data
-	hash, _, err := apitypes.TypedDataAndHash(typedData)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to hash typed data: %w", err)
+	return newSignature(hash, signature), nil
+}
+
+// newSignature builds a Signature from a digest and a 65-byte [R || S || V]
+// signature whose V is already 27/28
+func newSignature(hash, signature []byte) *Signature {
+	return &Signature{
+		R:     hexutil.Encode(signature[:32]),
+		S:     hexutil.Encode(signature[32:64]),
+		V:     uint8(signature[64]),
+		Hash:  hexutil.Encode(hash),
+		Bytes: hexutil.Encode(signature),
 	}
-	
+}
+
+// recoverHash recovers the address that produced a hex-encoded 65-byte
+// signature over a 32-byte digest
+func recoverHash(hash []byte, signatureHex string) (common.Address, error) {
 	// Decode signature
-	sigBytes, err := hexutil.Decode(sig.Bytes)
+	sigBytes, err := hexutil.Decode(signatureHex)
 	if err != nil {
 		return common.Address{}, fmt.Errorf("invalid signature hex: %w", err)
 	}
@@ -297,44 +365,31 @@ func (sig *Signature) Recover(domain Domain, types map[string][]Type, primaryTyp
 	return crypto.PubkeyToAddress(*pubKey), nil
 }
 
-// Helper functions
-
 func (s *Signer) domainToAPITypes(domain Domain) apitypes.TypedDataDomain {
-	d := apitypes.TypedDataDomain{
-		Name:    domain.Name,
-		Version: domain.Version,
-	}
-	
-	if domain.ChainID != nil {
-		d.ChainId = (*math.HexOrDecimal256)(domain.ChainID)
-	}
-	
-	if domain.VerifyingContract != (common.Address{}) {
-		d.VerifyingContract = domain.VerifyingContract.Hex()
-	}
-	
-	if domain.Salt != [32]byte{} {
-		d.Salt = hexutil.Encode(domain.Salt[:])
-	}
-	
-	return d
+	return domainToAPITypesStatic(domain)
 }
 
 func domainToAPITypesStatic(domain Domain) apitypes.TypedDataDomain {
-	d := apitypes.TypedDataDomain{
-		Name:    domain.Name,
-		Version: domain.Version,
+	fields := domain.FieldSet()
+	d := apitypes.TypedDataDomain{}
+	
+	if fields.Has(DomainFieldName) {
+		d.Name = domain.Name
 	}
 	
-	if domain.ChainID != nil {
-		d.ChainId = (*math.HexOrDecimal256)(domain.ChainID)
+	if fields.Has(DomainFieldVersion) {
+		d.Version = domain.Version
 	}
 	
-	if domain.VerifyingContract != (common.Address{}) {
+	if fields.Has(DomainFieldChainID) {
+		d.ChainId = (*math.HexOrDecimal256)(domainChainID(domain))
+	}
+	
+	if fields.Has(DomainFieldVerifyingContract) {
 		d.VerifyingContract = domain.VerifyingContract.Hex()
 	}
 	
-	if domain.Salt != [32]byte{} {
+	if fields.Has(DomainFieldSalt) {
 		d.Salt = hexutil.Encode(domain.Salt[:])
 	}
 	
@@ -346,24 +401,95 @@ func (s *Signer) buildDomainTypes(domain Domain) []apitypes.Type {
 }
 
 func buildDomainTypesStatic(domain Domain) []apitypes.Type {
-	types := []apitypes.Type{
-		{Name: "name", Type: "string"},
-		{Name: "version", Type: "string"},
+	fields := domain.FieldSet().Types()
+	types := make([]apitypes.Type, len(fields))
+	for i, field := range fields {
+		types[i] = apitypes.Type{Name: field.Name, Type: field.Type}
+	}
+	return types
+}
+
+// domainToMap returns the values of the named EIP712Domain fields, so that
+// declared fields are encoded even when they hold zero values
+func domainToMap(domain Domain, fields []Type) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field.Name {
+		case "name":
+			m["name"] = domain.Name
+		case "version":
+			m["version"] = domain.Version
+		case "chainId":
+			m["chainId"] = domainChainID(domain).String()
+		case "verifyingContract":
+			m["verifyingContract"] = domain.VerifyingContract.Hex()
+		case "salt":
+			m["salt"] = hexutil.Encode(domain.Salt[:])
+		default:
+			return nil, fmt.Errorf("unsupported EIP712Domain field: %s", field.Name)
+		}
+	}
+	return m, nil
+}
+
+// domainChainID returns the domain's chain ID, treating nil as zero
+func domainChainID(domain Domain) *big.Int {
+	if domain.ChainID == nil {
+		return new(big.Int)
+	}
+	return domain.ChainID
+}
+
+// typedDataHash computes the EIP-712 digest with go-ethereum's encoder.
+// domainTypes is used as the EIP712Domain type when types does not define
+// one. The domain is hashed from the declared fields rather than
+// apitypes.TypedDataDomain.Map, which drops empty values.
+func typedDataHash(domain Domain, types map[string][]Type, primaryType string, message Message, domainTypes []apitypes.Type) ([]byte, error) {
+	// Convert to apitypes format
+	typedData := apitypes.TypedData{
+		Types:       make(apitypes.Types, len(types)+1),
+		PrimaryType: primaryType,
+		Domain:      domainToAPITypesStatic(domain),
+		Message:     apitypes.TypedDataMessage(message),
+	}
+	
+	// Convert types
+	for typeName, fields := range types {
+		apiTypes := make([]apitypes.Type, len(fields))
+		for i, field := range fields {
+			apiTypes[i] = apitypes.Type{
+				Name: field.Name,
+				Type: field.Type,
+			}
+		}
+		typedData.Types[typeName] = apiTypes
+	}
+	
+	// Add EIP712Domain type if not present
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		typedData.Types["EIP712Domain"] = domainTypes
 	}
 	
-	if domain.ChainID != nil {
-		types = append(types, apitypes.Type{Name: "chainId", Type: "uint256"})
+	domainFields := make([]Type, len(typedData.Types["EIP712Domain"]))
+	for i, field := range typedData.Types["EIP712Domain"] {
+		domainFields[i] = Type{Name: field.Name, Type: field.Type}
+	}
+	domainData, err := domainToMap(domain, domainFields)
+	if err != nil {
+		return nil, err
 	}
 	
-	if domain.VerifyingContract != (common.Address{}) {
-		types = append(types, apitypes.Type{Name: "verifyingContract", Type: "address"})
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", domainData)
+	if err != nil {
+		return nil, err
 	}
 	
-	if domain.Salt != [32]byte{} {
-		types = append(types, apitypes.Type{Name: "salt", Type: "bytes32"})
+	messageHash, err := typedData.HashStruct(primaryType, typedData.Message)
+	if err != nil {
+		return nil, err
 	}
 	
-	return types
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
 }
 
 // Quick signing functions for common use cases
@@ -586,7 +712,7 @@ func checkCycle(typeName string, types map[string][]Type, visited, inPath map[st
 	if fields, ok := types[typeName]; ok {
 		for _, field := range fields {
 			// Extract base type (remove array notation)
-			baseType := strings.TrimSuffix(field.Type, "[]")
+			baseType := baseTypeName(field.Type)
 			
 			// Check if it's a custom type (not a primitive)
 			if _, isCustom := types[baseType]; isCustom {
@@ -600,3 +726,38 @@ func checkCycle(typeName string, types map[string][]Type, visited, inPath map[st
 	inPath[typeName] = false
 	return nil
 }
+
+// baseTypeName strips all array suffixes from a type, so both "Person[]" and
+// "Person[2][]" resolve to "Person"
+func baseTypeName(typ string) string {
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// splitArrayType splits the outermost array dimension from an array type.
+// For "Person[2][]" it returns "Person[2]" and -1 (dynamic); for "uint8[3]"
+// it returns "uint8" and 3. ok is false if typ is not an array type.
+func splitArrayType(typ string) (elementType string, length int, ok bool, err error) {
+	if !strings.HasSuffix(typ, "]") {
+		return typ, 0, false, nil
+	}
+	
+	open := strings.LastIndexByte(typ, '[')
+	if open <= 0 {
+		return "", 0, false, fmt.Errorf("invalid array type: %s", typ)
+	}
+	
+	elementType = typ[:open]
+	size := typ[open+1 : len(typ)-1]
+	if size == "" {
+		return elementType, -1, true, nil
+	}
+	
+	length, err = strconv.Atoi(size)
+	if err != nil || length < 1 {
+		return "", 0, false, fmt.Errorf("invalid array length in type: %s", typ)
+	}
+	return elementType, length, true, nil
+}
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -953,4 +955,119 @@ func TestInferTypesEdgeCases(t *testing.T) {
 			assert.Equal(t, expectedMap, resultMap)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestExplicitDomainFields(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	mailTypes := map[string][]Type{
+		"Mail": {
+			{Name: "contents", Type: "string"},
+		},
+	}
+	message := Message{"contents": "hello"}
+	messageHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("Mail(string contents)")),
+		crypto.Keccak256([]byte("hello")),
+	)
+	word := func(b []byte) []byte {
+		return common.LeftPadBytes(b, 32)
+	}
+
+	tests := []struct {
+		name      string
+		domain    Domain
+		types     map[string][]Type
+		separator []byte
+	}{
+		{
+			name: "zero chainId and verifyingContract",
+			domain: Domain{
+				Name:   "Test",
+				Fields: DomainFieldName | DomainFieldChainID | DomainFieldVerifyingContract,
+			},
+			types: mailTypes,
+			separator: crypto.Keccak256(
+				crypto.Keccak256([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)")),
+				crypto.Keccak256([]byte("Test")),
+				word(nil),
+				word(nil),
+			),
+		},
+		{
+			name: "chainId and verifyingContract only",
+			domain: Domain{
+				ChainID:           big.NewInt(1),
+				VerifyingContract: common.HexToAddress(testAddress1),
+				Fields:            DomainFieldChainID | DomainFieldVerifyingContract,
+			},
+			types: mailTypes,
+			separator: crypto.Keccak256(
+				crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,address verifyingContract)")),
+				word([]byte{1}),
+				word(common.HexToAddress(testAddress1).Bytes()),
+			),
+		},
+		{
+			name: "empty version and zero salt",
+			domain: Domain{
+				Name:   "Test",
+				Fields: DomainFieldName | DomainFieldVersion | DomainFieldSalt,
+			},
+			types: mailTypes,
+			separator: crypto.Keccak256(
+				crypto.Keccak256([]byte("EIP712Domain(string name,string version,bytes32 salt)")),
+				crypto.Keccak256([]byte("Test")),
+				crypto.Keccak256([]byte("")),
+				word(nil),
+			),
+		},
+		{
+			name:   "explicit field order",
+			domain: Domain{Name: "Test", ChainID: big.NewInt(5)},
+			types: map[string][]Type{
+				"EIP712Domain": {
+					{Name: "chainId", Type: "uint256"},
+					{Name: "name", Type: "string"},
+				},
+				"Mail": mailTypes["Mail"],
+			},
+			separator: crypto.Keccak256(
+				crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,string name)")),
+				word([]byte{5}),
+				crypto.Keccak256([]byte("Test")),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := crypto.Keccak256([]byte{0x19, 0x01}, tt.separator, messageHash)
+
+			sig, err := signer.SignTypedData(tt.domain, tt.types, "Mail", message)
+			require.NoError(t, err)
+			assert.Equal(t, hexutil.Encode(expected), sig.Hash)
+
+			fastHash, err := NewFastTypedDataEncoder(tt.domain, tt.types, "Mail", message).Hash()
+			require.NoError(t, err)
+			assert.Equal(t, expected, fastHash)
+
+			recovered, err := sig.Recover(tt.domain, tt.types, "Mail", message)
+			require.NoError(t, err)
+			assert.Equal(t, signer.Address(), recovered)
+		})
+	}
+
+	t.Run("unsupported domain field", func(t *testing.T) {
+		types := map[string][]Type{
+			"EIP712Domain": {{Name: "owner", Type: "address"}},
+			"Mail":         mailTypes["Mail"],
+		}
+		_, err := signer.SignTypedData(Domain{Name: "Test"}, types, "Mail", message)
+		assert.Error(t, err)
+
+		_, err = NewFastTypedDataEncoder(Domain{Name: "Test"}, types, "Mail", message).Hash()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,739 @@
+package eip712
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrNoDescriptor is returned when no ERC-7730 descriptor covers a message
+var ErrNoDescriptor = errors.New("no clear-signing descriptor for message")
+
+// Descriptor is an ERC-7730 clear-signing descriptor for EIP-712 messages.
+// It binds display formats to the domains and schemas it covers.
+// Descriptors using "includes" must be merged, and schemas given by URL
+// inlined, by the caller before parsing.
+type Descriptor struct {
+	Context struct {
+		EIP712 *struct {
+			Deployments []struct {
+				ChainID uint64         `json:"chainId"`
+				Address common.Address `json:"address"`
+			} `json:"deployments"`
+			Domain  map[string]interface{} `json:"domain"`
+			Schemas []json.RawMessage      `json:"schemas"`
+		} `json:"eip712"`
+	} `json:"context"`
+	Metadata struct {
+		Owner string `json:"owner"`
+	} `json:"metadata"`
+	Display struct {
+		Definitions map[string]descriptorField  `json:"definitions"`
+		Formats     map[string]descriptorFormat `json:"formats"`
+	} `json:"display"`
+
+	// raw is the whole document, for resolving "$." references
+	raw map[string]interface{}
+	// schemas are the encodeType strings of the primary types of the
+	// descriptor's schemas
+	schemas map[string]bool
+}
+
+// descriptorFormat is the display of one primary type
+type descriptorFormat struct {
+	Intent interface{}       `json:"intent"`
+	Fields []descriptorField `json:"fields"`
+}
+
+// descriptorField is one displayed field, a reference to a definition, or
+// a group of nested fields
+type descriptorField struct {
+	Path   string                 `json:"path"`
+	Label  string                 `json:"label"`
+	Format string                 `json:"format"`
+	Params map[string]interface{} `json:"params"`
+	Ref    string                 `json:"$ref"`
+	Fields []descriptorField      `json:"fields"`
+}
+
+// TokenInfo is the display metadata of an ERC-20 token
+type TokenInfo struct {
+	Symbol   string
+	Decimals int
+}
+
+// ClearSignOptions supplies what a wallet knows beyond the descriptor
+type ClearSignOptions struct {
+	// Tokens resolves tokenAmount fields
+	Tokens map[common.Address]TokenInfo
+	// AddressNames resolves addressName fields, e.g. from an address book
+	AddressNames map[common.Address]string
+	// Signer is the container value "@.from"
+	Signer common.Address
+	// NativeSymbol is the currency of amount fields; empty means ETH
+	NativeSymbol string
+	// Location is the time zone of date fields; nil means UTC
+	Location *time.Location
+}
+
+// ClearSignField is one formatted line of a clear-signing display
+type ClearSignField struct {
+	Label  string `json:"label"`
+	Value  string `json:"value"`
+	Path   string `json:"path"`
+	Format string `json:"format"`
+}
+
+// ClearSigning is what a wallet displays for a message
+type ClearSigning struct {
+	Owner  string           `json:"owner,omitempty"`
+	Intent string           `json:"intent,omitempty"`
+	Fields []ClearSignField `json:"fields"`
+}
+
+// ParseDescriptor parses an ERC-7730 JSON descriptor
+func ParseDescriptor(data []byte) (*Descriptor, error) {
+	var d Descriptor
+	if err := decodeDescriptorJSON(data, &d); err != nil {
+		return nil, fmt.Errorf("invalid ERC-7730 descriptor: %w", err)
+	}
+	if d.Context.EIP712 == nil {
+		return nil, errors.New("invalid ERC-7730 descriptor: not an EIP-712 descriptor")
+	}
+	if err := decodeDescriptorJSON(data, &d.raw); err != nil {
+		return nil, fmt.Errorf("invalid ERC-7730 descriptor: %w", err)
+	}
+
+	d.schemas = make(map[string]bool, len(d.Context.EIP712.Schemas))
+	for i, raw := range d.Context.EIP712.Schemas {
+		var schema struct {
+			Types       map[string][]Type `json:"types"`
+			PrimaryType string            `json:"primaryType"`
+		}
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("invalid ERC-7730 descriptor: schema %d must be an inline types object: %w", i, err)
+		}
+		encoded, err := NewFastTypedDataEncoder(Domain{}, schema.Types, schema.PrimaryType, nil).encodeType(schema.PrimaryType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ERC-7730 descriptor: schema %d: %w", i, err)
+		}
+		d.schemas[encoded] = true
+	}
+	return &d, nil
+}
+
+// decodeDescriptorJSON decodes with numbers kept as json.Number, so that
+// parameters such as thresholds keep their exact value
+func decodeDescriptorJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// LoadDescriptor reads and parses an ERC-7730 descriptor file
+func LoadDescriptor(path string) (*Descriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor: %w", err)
+	}
+	d, err := ParseDescriptor(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Matches reports whether the descriptor covers primaryType messages in
+// domain: the domain must be one of its deployments, agree with any domain
+// values it pins, the message schema must be one of its schemas, and the
+// descriptor must have a format for the type. A descriptor without schemas
+// matches on the format alone.
+func (d *Descriptor) Matches(domain Domain, types map[string][]Type, primaryType string) bool {
+	eip712 := d.Context.EIP712
+	if eip712 == nil {
+		return false
+	}
+	if len(d.schemas) > 0 {
+		encoded, err := NewFastTypedDataEncoder(Domain{}, types, primaryType, nil).encodeType(primaryType)
+		if err != nil || !d.schemas[encoded] {
+			return false
+		}
+	}
+	if len(eip712.Deployments) > 0 {
+		deployed := false
+		for _, deployment := range eip712.Deployments {
+			if domain.ChainID != nil && domain.ChainID.IsUint64() && domain.ChainID.Uint64() == deployment.ChainID &&
+				domain.VerifyingContract == deployment.Address {
+				deployed = true
+				break
+			}
+		}
+		if !deployed {
+			return false
+		}
+	}
+
+	for key, want := range eip712.Domain {
+		var got string
+		switch key {
+		case "name":
+			got = domain.Name
+		case "version":
+			got = domain.Version
+		case "chainId":
+			if domain.ChainID != nil {
+				got = domain.ChainID.String()
+			}
+		case "verifyingContract":
+			if !strings.EqualFold(fmt.Sprint(want), domain.VerifyingContract.Hex()) {
+				return false
+			}
+			continue
+		default:
+			continue
+		}
+		if fmt.Sprint(want) != got {
+			return false
+		}
+	}
+
+	_, ok := d.format(types, primaryType)
+	return ok
+}
+
+// format returns the display format of primaryType, keyed either by the
+// type name or by its full encodeType string
+func (d *Descriptor) format(types map[string][]Type, primaryType string) (descriptorFormat, bool) {
+	if format, ok := d.Display.Formats[primaryType]; ok {
+		return format, true
+	}
+	encoded, err := NewFastTypedDataEncoder(Domain{}, types, primaryType, nil).encodeType(primaryType)
+	if err != nil {
+		return descriptorFormat{}, false
+	}
+	format, ok := d.Display.Formats[encoded]
+	return format, ok
+}
+
+// Format produces the clear-signing display of a message the descriptor
+// matches
+//
+// Example:
+//
+//	descriptor, _ := LoadDescriptor("registry/uniswap/eip712-permit2.json")
+//	display, err := descriptor.Format(domain, types, "PermitSingle", message, ClearSignOptions{
+//	    Tokens: map[common.Address]TokenInfo{usdc: {Symbol: "USDC", Decimals: 6}},
+//	})
+//	for _, field := range display.Fields {
+//	    fmt.Printf("%s: %s\n", field.Label, field.Value)
+//	}
+func (d *Descriptor) Format(domain Domain, types map[string][]Type, primaryType string, message Message, opts ClearSignOptions) (*ClearSigning, error) {
+	if !d.Matches(domain, types, primaryType) {
+		return nil, fmt.Errorf("%w: %s", ErrNoDescriptor, primaryType)
+	}
+	format, _ := d.format(types, primaryType)
+
+	f := &clearSignFormatter{descriptor: d, domain: domain, message: message, opts: opts}
+	if f.opts.NativeSymbol == "" {
+		f.opts.NativeSymbol = "ETH"
+	}
+	if f.opts.Location == nil {
+		f.opts.Location = time.UTC
+	}
+
+	display := &ClearSigning{Owner: d.Metadata.Owner, Fields: []ClearSignField{}}
+	switch intent := format.Intent.(type) {
+	case string:
+		display.Intent = intent
+	case map[string]interface{}:
+		keys := make([]string, 0, len(intent))
+		for key := range intent {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = fmt.Sprintf("%s: %v", key, intent[key])
+		}
+		display.Intent = strings.Join(parts, ", ")
+	}
+
+	if err := f.formatFields(display, "", format.Fields); err != nil {
+		return nil, err
+	}
+	return display, nil
+}
+
+// DescriptorRegistry holds descriptors and finds the one for a message. It
+// is safe for concurrent use.
+type DescriptorRegistry struct {
+	mu          sync.RWMutex
+	descriptors []*Descriptor
+}
+
+// NewDescriptorRegistry returns a registry holding descriptors
+func NewDescriptorRegistry(descriptors ...*Descriptor) *DescriptorRegistry {
+	return &DescriptorRegistry{descriptors: descriptors}
+}
+
+// Add registers a descriptor
+func (r *DescriptorRegistry) Add(d *Descriptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.descriptors = append(r.descriptors, d)
+}
+
+// LoadDir registers every EIP-712 descriptor among the .json files in dir
+// and its subdirectories, such as a checkout of the ERC-7730 registry.
+// Files describing contract calldata are skipped.
+func (r *DescriptorRegistry) LoadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var probe struct {
+			Context struct {
+				EIP712 json.RawMessage `json:"eip712"`
+			} `json:"context"`
+		}
+		if json.Unmarshal(data, &probe) != nil || probe.Context.EIP712 == nil {
+			return nil
+		}
+		d, err := ParseDescriptor(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		r.Add(d)
+		return nil
+	})
+}
+
+// Find returns the first descriptor matching the message
+func (r *DescriptorRegistry) Find(domain Domain, types map[string][]Type, primaryType string) (*Descriptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, d := range r.descriptors {
+		if d.Matches(domain, types, primaryType) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s on %s at %s", ErrNoDescriptor, primaryType,
+		DefaultChainRegistry.Describe(domain.ChainID), domain.VerifyingContract.Hex())
+}
+
+// Format finds the descriptor for the message and formats it
+func (r *DescriptorRegistry) Format(domain Domain, types map[string][]Type, primaryType string, message Message, opts ClearSignOptions) (*ClearSigning, error) {
+	d, err := r.Find(domain, types, primaryType)
+	if err != nil {
+		return nil, err
+	}
+	return d.Format(domain, types, primaryType, message, opts)
+}
+
+// clearSignFormatter holds the state of one Format call
+type clearSignFormatter struct {
+	descriptor *Descriptor
+	domain     Domain
+	message    Message
+	opts       ClearSignOptions
+}
+
+// formatFields formats a field list whose paths are relative to prefix
+func (f *clearSignFormatter) formatFields(display *ClearSigning, prefix string, fields []descriptorField) error {
+	for _, field := range fields {
+		field, err := f.resolveField(field)
+		if err != nil {
+			return err
+		}
+		path := joinDescriptorPath(prefix, field.Path)
+
+		if field.Fields != nil {
+			if err := f.formatFields(display, path, field.Fields); err != nil {
+				return err
+			}
+			continue
+		}
+
+		values, err := f.values(path)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			formatted, err := f.formatValue(field, value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			display.Fields = append(display.Fields, ClearSignField{
+				Label:  field.Label,
+				Value:  formatted,
+				Path:   path,
+				Format: field.Format,
+			})
+		}
+	}
+	return nil
+}
+
+// resolveField merges a field with the definition it references; the
+// field's own label, format and params take precedence
+func (f *clearSignFormatter) resolveField(field descriptorField) (descriptorField, error) {
+	if field.Ref == "" {
+		return field, nil
+	}
+	name := strings.TrimPrefix(field.Ref, "$.display.definitions.")
+	def, ok := f.descriptor.Display.Definitions[name]
+	if !ok {
+		return field, fmt.Errorf("unknown definition %s", field.Ref)
+	}
+
+	if field.Label == "" {
+		field.Label = def.Label
+	}
+	if field.Format == "" {
+		field.Format = def.Format
+	}
+	params := make(map[string]interface{}, len(def.Params)+len(field.Params))
+	for key, value := range def.Params {
+		params[key] = value
+	}
+	for key, value := range field.Params {
+		params[key] = value
+	}
+	field.Params = params
+	return field, nil
+}
+
+// values returns the values at a descriptor path. A "[]" segment expands
+// to every element of an array, so one path may yield several values.
+func (f *clearSignFormatter) values(path string) ([]interface{}, error) {
+	switch {
+	case strings.HasPrefix(path, "@."):
+		value, err := f.containerValue(path)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	case strings.HasPrefix(path, "$."):
+		value, ok := f.constant(path)
+		if !ok {
+			return nil, fmt.Errorf("unknown constant %s", path)
+		}
+		return []interface{}{value}, nil
+	}
+
+	current := []interface{}{map[string]interface{}(f.message)}
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "#."), ".") {
+		var next []interface{}
+		for _, value := range current {
+			expanded, err := descriptorSegment(value, segment)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			next = append(next, expanded...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// descriptorSegment applies one path segment: a field name, "[]" or "[n]"
+func descriptorSegment(value interface{}, segment string) ([]interface{}, error) {
+	if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
+		slice := reflect.ValueOf(value)
+		if slice.Kind() != reflect.Slice && slice.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%s applied to %T", segment, value)
+		}
+		if segment == "[]" {
+			out := make([]interface{}, slice.Len())
+			for i := range out {
+				out[i] = slice.Index(i).Interface()
+			}
+			return out, nil
+		}
+		index, err := strconv.Atoi(segment[1 : len(segment)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %s", segment)
+		}
+		if index < 0 {
+			index += slice.Len()
+		}
+		if index < 0 || index >= slice.Len() {
+			return nil, fmt.Errorf("index %s out of range", segment)
+		}
+		return []interface{}{slice.Index(index).Interface()}, nil
+	}
+
+	var fields map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fields = v
+	case Message:
+		fields = v
+	default:
+		return nil, fmt.Errorf("field %s of non-struct %T", segment, value)
+	}
+	field, ok := fields[segment]
+	if !ok {
+		return nil, fmt.Errorf("missing field %s", segment)
+	}
+	return []interface{}{field}, nil
+}
+
+// containerValue returns an "@." container value of the EIP-712 message
+func (f *clearSignFormatter) containerValue(path string) (interface{}, error) {
+	switch path {
+	case "@.from":
+		return f.opts.Signer, nil
+	case "@.to":
+		return f.domain.VerifyingContract, nil
+	case "@.chainId":
+		return f.domain.ChainID, nil
+	default:
+		return nil, fmt.Errorf("unsupported container path %s", path)
+	}
+}
+
+// constant resolves a "$." reference into the descriptor document
+func (f *clearSignFormatter) constant(path string) (interface{}, bool) {
+	var current interface{} = f.descriptor.raw
+	for _, name := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = fields[name]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// param returns a parameter with "$." references resolved
+func (f *clearSignFormatter) param(field descriptorField, name string) (interface{}, bool) {
+	value, ok := field.Params[name]
+	if !ok {
+		return nil, false
+	}
+	if ref, isString := value.(string); isString && strings.HasPrefix(ref, "$.") {
+		return f.constant(ref)
+	}
+	return value, true
+}
+
+// paramAddress reads an address given directly by param or at the message
+// path given by pathParam
+func (f *clearSignFormatter) paramAddress(field descriptorField, param, pathParam string) (common.Address, bool, error) {
+	if path, ok := field.Params[pathParam].(string); ok {
+		values, err := f.values(path)
+		if err != nil {
+			return common.Address{}, false, err
+		}
+		if len(values) != 1 {
+			return common.Address{}, false, fmt.Errorf("%s %s is not a single value", pathParam, path)
+		}
+		address, err := toAddress(values[0])
+		return address, err == nil, err
+	}
+	if value, ok := f.param(field, param); ok {
+		address, err := toAddress(value)
+		return address, err == nil, err
+	}
+	return common.Address{}, false, nil
+}
+
+// formatValue renders one value in the field's format
+func (f *clearSignFormatter) formatValue(field descriptorField, value interface{}) (string, error) {
+	switch field.Format {
+	case "addressName":
+		address, err := toAddress(value)
+		if err != nil {
+			return "", err
+		}
+		if name, ok := f.opts.AddressNames[address]; ok {
+			return name, nil
+		}
+		return address.Hex(), nil
+
+	case "tokenAmount":
+		return f.formatTokenAmount(field, value)
+
+	case "amount":
+		n, err := messageInt(value)
+		if err != nil {
+			return "", err
+		}
+		return formatUnits(n, 18) + " " + f.opts.NativeSymbol, nil
+
+	case "date":
+		n, err := messageInt(value)
+		if err != nil {
+			return "", err
+		}
+		if encoding, _ := field.Params["encoding"].(string); encoding == "blockheight" {
+			return "block " + n.String(), nil
+		}
+		if n.Sign() < 0 || n.Cmp(maxUnixTime) > 0 {
+			return n.String(), nil
+		}
+		return time.Unix(n.Int64(), 0).In(f.opts.Location).Format("2006-01-02 15:04:05 MST"), nil
+
+	case "duration":
+		n, err := messageInt(value)
+		if err != nil {
+			return "", err
+		}
+		if !n.IsInt64() {
+			return n.String(), nil
+		}
+		seconds := n.Int64()
+		return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60), nil
+
+	case "unit":
+		n, err := messageInt(value)
+		if err != nil {
+			return "", err
+		}
+		decimals := 0
+		if raw, ok := f.param(field, "decimals"); ok {
+			d, err := canonicalInteger(raw)
+			if err != nil || !d.IsInt64() {
+				return "", fmt.Errorf("invalid decimals: %v", raw)
+			}
+			decimals = int(d.Int64())
+		}
+		base, _ := f.param(field, "base")
+		return formatUnits(n, decimals) + fmt.Sprint(base), nil
+
+	case "enum":
+		ref, _ := field.Params["$ref"].(string)
+		constant, ok := f.constant(ref)
+		if !ok {
+			return "", fmt.Errorf("unknown enum %s", ref)
+		}
+		enum, ok := constant.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("enum %s is not an object", ref)
+		}
+		key := fmt.Sprint(value)
+		if n, err := messageInt(value); err == nil {
+			key = n.String()
+		}
+		if label, ok := enum[key]; ok {
+			return fmt.Sprint(label), nil
+		}
+		return key, nil
+
+	case "nftName":
+		n, err := messageInt(value)
+		if err != nil {
+			return "", err
+		}
+		collection, ok, err := f.paramAddress(field, "collection", "collectionPath")
+		if err != nil || !ok {
+			return "#" + n.String(), err
+		}
+		name, known := f.opts.AddressNames[collection]
+		if !known {
+			name = collection.Hex()
+		}
+		return fmt.Sprintf("%s #%s", name, n), nil
+
+	default:
+		// raw, calldata and formats this package does not render
+		return formatRaw(value)
+	}
+}
+
+// formatTokenAmount renders an amount of the token given by the field's
+// token or tokenPath parameter, showing the threshold message (by default
+// "Unlimited") for amounts at or above the threshold parameter
+func (f *clearSignFormatter) formatTokenAmount(field descriptorField, value interface{}) (string, error) {
+	n, err := messageInt(value)
+	if err != nil {
+		return "", err
+	}
+	token, hasToken, err := f.paramAddress(field, "token", "tokenPath")
+	if err != nil {
+		return "", err
+	}
+	info, known := f.opts.Tokens[token]
+
+	if raw, ok := f.param(field, "threshold"); ok {
+		threshold, err := canonicalInteger(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid threshold: %w", err)
+		}
+		if n.Cmp(threshold) >= 0 {
+			message := "Unlimited"
+			if m, ok := f.param(field, "message"); ok {
+				message = fmt.Sprint(m)
+			}
+			if known {
+				message += " " + info.Symbol
+			}
+			return message, nil
+		}
+	}
+
+	switch {
+	case known:
+		return formatUnits(n, info.Decimals) + " " + info.Symbol, nil
+	case hasToken:
+		return fmt.Sprintf("%s (unknown token %s)", n, token.Hex()), nil
+	default:
+		return n.String(), nil
+	}
+}
+
+// formatRaw renders a value without a format
+func formatRaw(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex(), nil
+	case []byte:
+		return hexutil.Encode(v), nil
+	case [32]byte:
+		return hexutil.Encode(v[:]), nil
+	case common.Hash:
+		return v.Hex(), nil
+	case *big.Int:
+		return v.String(), nil
+	case string:
+		if common.IsHexAddress(v) && len(v) == 42 {
+			return common.HexToAddress(v).Hex(), nil
+		}
+		return v, nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// joinDescriptorPath resolves a field path against its group's path.
+// Absolute paths ("#.", "@.", "$.") ignore the prefix.
+func joinDescriptorPath(prefix, path string) string {
+	if prefix == "" || strings.HasPrefix(path, "#.") || strings.HasPrefix(path, "@.") || strings.HasPrefix(path, "$.") {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return prefix + "." + path
+}
@@ -0,0 +1,261 @@
+package eip712
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const permit2Address = "0x000000000022D473030F116dDEE9F6B43aC78BA3"
+
+// testPermit2Descriptor follows the registry's Permit2 descriptor, with an
+// enum added to exercise metadata references
+const testPermit2Descriptor = `{
+  "context": {
+    "eip712": {
+      "deployments": [
+        {"chainId": 1, "address": "0x000000000022D473030F116dDEE9F6B43aC78BA3"},
+        {"chainId": 137, "address": "0x000000000022D473030F116dDEE9F6B43aC78BA3"}
+      ],
+      "domain": {"name": "Permit2"},
+      "schemas": [{
+        "primaryType": "PermitSingle",
+        "types": {
+          "EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}, {"name": "verifyingContract", "type": "address"}],
+          "PermitSingle": [{"name": "details", "type": "PermitDetails"}, {"name": "spender", "type": "address"}, {"name": "sigDeadline", "type": "uint256"}, {"name": "kind", "type": "uint8"}],
+          "PermitDetails": [{"name": "token", "type": "address"}, {"name": "amount", "type": "uint160"}, {"name": "expiration", "type": "uint48"}, {"name": "nonce", "type": "uint48"}]
+        }
+      }]
+    }
+  },
+  "metadata": {
+    "owner": "Uniswap",
+    "constants": {"max": "0xffffffffffffffffffffffffffffffffffffffff"},
+    "enums": {"kind": {"0": "Transfer", "1": "Approval"}}
+  },
+  "display": {
+    "definitions": {
+      "amount": {"label": "Amount allowance", "format": "tokenAmount", "params": {"tokenPath": "details.token", "threshold": "$.metadata.constants.max"}}
+    },
+    "formats": {
+      "PermitSingle(PermitDetails details,address spender,uint256 sigDeadline,uint8 kind)PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)": {
+        "intent": "Approve token spending",
+        "fields": [
+          {"path": "spender", "label": "Approve to spender", "format": "addressName"},
+          {"path": "details", "fields": [
+            {"path": "amount", "$ref": "$.display.definitions.amount"},
+            {"path": "expiration", "label": "Approval expires", "format": "date", "params": {"encoding": "timestamp"}}
+          ]},
+          {"path": "kind", "label": "Kind", "format": "enum", "params": {"$ref": "$.metadata.enums.kind"}},
+          {"path": "sigDeadline", "label": "Signature deadline", "format": "date", "params": {"encoding": "timestamp"}},
+          {"path": "@.to", "label": "Contract", "format": "raw"}
+        ]
+      }
+    }
+  }
+}`
+
+func createPermitSingle(amount string) (Domain, map[string][]Type, Message) {
+	domain := createTestDomainWithContract("Permit2", "", 1, permit2Address)
+	types := map[string][]Type{
+		"PermitSingle": {
+			{Name: "details", Type: "PermitDetails"},
+			{Name: "spender", Type: "address"},
+			{Name: "sigDeadline", Type: "uint256"},
+			{Name: "kind", Type: "uint8"},
+		},
+		"PermitDetails": {
+			{Name: "token", Type: "address"},
+			{Name: "amount", Type: "uint160"},
+			{Name: "expiration", Type: "uint48"},
+			{Name: "nonce", Type: "uint48"},
+		},
+	}
+	message := Message{
+		"details": map[string]interface{}{
+			"token":      testUSDC,
+			"amount":     amount,
+			"expiration": "1893456000",
+			"nonce":      "0",
+		},
+		"spender":     testRouter,
+		"sigDeadline": big.NewInt(1893459600),
+		"kind":        1,
+	}
+	return domain, types, message
+}
+
+func TestDescriptorFormat(t *testing.T) {
+	descriptor, err := ParseDescriptor([]byte(testPermit2Descriptor))
+	require.NoError(t, err)
+
+	domain, types, message := createPermitSingle("2500000")
+	opts := ClearSignOptions{
+		Tokens:       map[common.Address]TokenInfo{testUSDC: {Symbol: "USDC", Decimals: 6}},
+		AddressNames: map[common.Address]string{testRouter: "Uniswap Universal Router"},
+	}
+
+	display, err := descriptor.Format(domain, types, "PermitSingle", message, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Uniswap", display.Owner)
+	assert.Equal(t, "Approve token spending", display.Intent)
+	assert.Equal(t, []ClearSignField{
+		{Label: "Approve to spender", Value: "Uniswap Universal Router", Path: "spender", Format: "addressName"},
+		{Label: "Amount allowance", Value: "2.5 USDC", Path: "details.amount", Format: "tokenAmount"},
+		{Label: "Approval expires", Value: "2030-01-01 00:00:00 UTC", Path: "details.expiration", Format: "date"},
+		{Label: "Kind", Value: "Approval", Path: "kind", Format: "enum"},
+		{Label: "Signature deadline", Value: "2030-01-01 01:00:00 UTC", Path: "sigDeadline", Format: "date"},
+		{Label: "Contract", Value: permit2Address, Path: "@.to", Format: "raw"},
+	}, display.Fields)
+
+	t.Run("unlimited", func(t *testing.T) {
+		_, _, message := createPermitSingle(unlimitedApproval.String())
+		display, err := descriptor.Format(domain, types, "PermitSingle", message, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "Unlimited USDC", display.Fields[1].Value)
+	})
+
+	t.Run("unknown names", func(t *testing.T) {
+		display, err := descriptor.Format(domain, types, "PermitSingle", message, ClearSignOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, testRouter.Hex(), display.Fields[0].Value)
+		assert.Equal(t, "2500000 (unknown token "+testUSDC.Hex()+")", display.Fields[1].Value)
+	})
+}
+
+func TestDescriptorMatches(t *testing.T) {
+	descriptor, err := ParseDescriptor([]byte(testPermit2Descriptor))
+	require.NoError(t, err)
+	domain, types, _ := createPermitSingle("1")
+
+	assert.True(t, descriptor.Matches(domain, types, "PermitSingle"))
+	assert.True(t, descriptor.Matches(createTestDomainWithContract("Permit2", "", 137, permit2Address), types, "PermitSingle"))
+	assert.False(t, descriptor.Matches(createTestDomainWithContract("Permit2", "", 10, permit2Address), types, "PermitSingle"), "undeployed chain")
+	assert.False(t, descriptor.Matches(createTestDomainWithContract("Permit2", "", 1, testUSDC.Hex()), types, "PermitSingle"), "other contract")
+	assert.False(t, descriptor.Matches(createTestDomainWithContract("Fake", "", 1, permit2Address), types, "PermitSingle"), "domain name")
+
+	// Formats keyed by encodeType only match the exact schema
+	renamed := map[string][]Type{"PermitSingle": types["PermitSingle"], "PermitDetails": types["PermitDetails"][:3]}
+	assert.False(t, descriptor.Matches(domain, renamed, "PermitSingle"))
+
+	_, err = descriptor.Format(domain, renamed, "PermitSingle", Message{}, ClearSignOptions{})
+	assert.ErrorIs(t, err, ErrNoDescriptor)
+
+	// Formats keyed by name match only the descriptor's schemas
+	byName, err := ParseDescriptor([]byte(`{
+	  "context": {"eip712": {"schemas": [{"primaryType": "M", "types": {"M": [{"name": "a", "type": "uint256"}]}}]}},
+	  "display": {"formats": {"M": {"fields": [{"path": "a", "label": "A", "format": "raw"}]}}}
+	}`))
+	require.NoError(t, err)
+	assert.True(t, byName.Matches(domain, map[string][]Type{"M": {{Name: "a", Type: "uint256"}}}, "M"))
+	assert.False(t, byName.Matches(domain, map[string][]Type{"M": {{Name: "a", Type: "address"}}}, "M"))
+
+	// Descriptors of other contexts never match
+	assert.False(t, (&Descriptor{}).Matches(domain, types, "PermitSingle"))
+
+	_, err = ParseDescriptor([]byte(`{"context": {"eip712": {"schemas": ["https://example.com/schema.json"]}}}`))
+	assert.Error(t, err)
+}
+
+func TestDescriptorNumericThreshold(t *testing.T) {
+	descriptor, err := ParseDescriptor([]byte(`{
+	  "context": {"eip712": {}},
+	  "display": {"formats": {"M": {"fields": [
+	    {"path": "a", "label": "A", "format": "tokenAmount", "params": {"threshold": 1000000000000000000000001}},
+	    {"path": "a", "label": "Gwei", "format": "unit", "params": {"base": "gwei", "decimals": 9}}
+	  ]}}}
+	}`))
+	require.NoError(t, err)
+
+	types := map[string][]Type{"M": {{Name: "a", Type: "uint256"}}}
+	domain := createTestDomain("App", "1", 1)
+	// As a float64 the threshold would round down to 1e24, making this unlimited
+	display, err := descriptor.Format(domain, types, "M", Message{"a": "1000000000000000000000000"}, ClearSignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000000000000", display.Fields[0].Value)
+	assert.Equal(t, "1000000000000000gwei", display.Fields[1].Value)
+
+	display, err = descriptor.Format(domain, types, "M", Message{"a": "1000000000000000000000001"}, ClearSignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Unlimited", display.Fields[0].Value)
+}
+
+func TestDescriptorArrays(t *testing.T) {
+	descriptor, err := ParseDescriptor([]byte(`{
+	  "context": {"eip712": {"deployments": [{"chainId": 1, "address": "` + testAddress2 + `"}]}},
+	  "metadata": {"owner": "Test"},
+	  "display": {"formats": {"Batch": {
+	    "intent": {"Send": "tokens"},
+	    "fields": [
+	      {"path": "amounts.[]", "label": "Amount", "format": "amount"},
+	      {"path": "#.amounts.[-1]", "label": "Last", "format": "unit", "params": {"base": "gwei", "decimals": 9}},
+	      {"path": "window", "label": "Window", "format": "duration"},
+	      {"path": "data", "label": "Data", "format": "calldata"}
+	    ]
+	  }}}
+	}`))
+	require.NoError(t, err)
+
+	domain := createTestDomainWithContract("Batch", "1", 1, testAddress2)
+	types := map[string][]Type{"Batch": {
+		{Name: "amounts", Type: "uint256[]"},
+		{Name: "window", Type: "uint32"},
+		{Name: "data", Type: "bytes"},
+	}}
+	message := Message{
+		"amounts": []interface{}{"1000000000000000000", "1500000000"},
+		"window":  3725,
+		"data":    []byte{0xca, 0xfe},
+	}
+
+	display, err := descriptor.Format(domain, types, "Batch", message, ClearSignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Send: tokens", display.Intent)
+
+	values := make([]string, len(display.Fields))
+	for i, field := range display.Fields {
+		values[i] = field.Value
+	}
+	assert.Equal(t, []string{"1 ETH", "0.0000000015 ETH", "1.5gwei", "01:02:05", "0xcafe"}, values)
+}
+
+func TestDescriptorRegistry(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "uniswap"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "uniswap", "eip712-permit2.json"), []byte(testPermit2Descriptor), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "uniswap", "calldata-router.json"), []byte(`{"context": {"contract": {}}}`), 0o644))
+
+	registry := NewDescriptorRegistry()
+	require.NoError(t, registry.LoadDir(dir))
+
+	domain, types, message := createPermitSingle("1000000")
+	display, err := registry.Format(domain, types, "PermitSingle", message, ClearSignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Uniswap", display.Owner)
+
+	_, err = registry.Find(createTestDomain("Other", "1", 1), createMailTypes(), "Mail")
+	assert.ErrorIs(t, err, ErrNoDescriptor)
+
+	_, err = ParseDescriptor([]byte(`{"context": {"contract": {}}}`))
+	assert.Error(t, err)
+}
+
+func TestDescriptorMalformedEnum(t *testing.T) {
+	descriptor, err := ParseDescriptor([]byte(`{
+	  "context": {"eip712": {}},
+	  "metadata": {"enums": {"kind": "Transfer"}},
+	  "display": {"formats": {"M": {"fields": [
+	    {"path": "kind", "label": "Kind", "format": "enum", "params": {"$ref": "$.metadata.enums.kind"}}
+	  ]}}}
+	}`))
+	require.NoError(t, err)
+
+	types := map[string][]Type{"M": {{Name: "kind", Type: "uint8"}}}
+	_, err = descriptor.Format(createTestDomain("App", "1", 1), types, "M", Message{"kind": 1}, ClearSignOptions{})
+	assert.ErrorContains(t, err, "not an object")
+}
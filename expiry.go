@@ -0,0 +1,240 @@
+package eip712
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrExpired is returned when a message deadline has passed
+	ErrExpired = errors.New("message expired")
+	// ErrNotYetValid is returned when a message's validity has not started
+	ErrNotYetValid = errors.New("message not yet valid")
+	// ErrDeadlineTooFar is returned when a deadline exceeds the policy's
+	// maximum time to live
+	ErrDeadlineTooFar = errors.New("message deadline too far in the future")
+)
+
+// Clock tells the current time. Policies take one so tests can fix it.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that reads the system time
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// ExpiryPolicy constrains the unix-timestamp fields that bound a message's
+// validity. Field names may be dotted paths into nested structs, such as
+// "info.deadline" for UniswapX orders. Fields absent from a message are
+// skipped unless Required is set.
+type ExpiryPolicy struct {
+	// Deadlines are fields after which the message is invalid, such as
+	// "deadline" or "validBefore". Each must not have passed and, if MaxTTL
+	// is set, must be at most MaxTTL away.
+	Deadlines []string
+	// ValidAfter are fields before which the message is invalid, such as
+	// "validAfter" or "startTime". They are only enforced when verifying,
+	// since signing a message in advance is normal.
+	ValidAfter []string
+	// MaxTTL bounds how far in the future a deadline may be; zero means no
+	// bound
+	MaxTTL time.Duration
+	// Leeway is the clock skew tolerated on every comparison
+	Leeway time.Duration
+	// Required makes a missing field an error
+	Required bool
+	// Clock supplies the current time; nil means SystemClock
+	Clock Clock
+}
+
+// DefaultExpiryPolicy returns a policy over the timestamp fields of common
+// standards: EIP-2612 and Permit2 "deadline", EIP-3009
+// "validBefore"/"validAfter" and Seaport "endTime"/"startTime"
+//
+// Example:
+//
+//	signer.SetExpiryPolicy(DefaultExpiryPolicy(time.Hour))
+//	_, err := signer.SignPermit(token, "USD Coin", "2", spender, value, nonce, staleDeadline)
+//	// errors.Is(err, ErrExpired)
+func DefaultExpiryPolicy(maxTTL time.Duration) *ExpiryPolicy {
+	return &ExpiryPolicy{
+		Deadlines:  []string{"deadline", "validBefore", "endTime"},
+		ValidAfter: []string{"validAfter", "startTime"},
+		MaxTTL:     maxTTL,
+	}
+}
+
+// SetExpiryPolicy makes the signer check every message against policy
+// before signing it; nil disables the checks. It is safe to call while the
+// signer is in use.
+func (s *Signer) SetExpiryPolicy(policy *ExpiryPolicy) {
+	s.key.mu.Lock()
+	defer s.key.mu.Unlock()
+	s.expiry = policy
+}
+
+// SetExpiryPolicy makes the signer check every message against policy
+// before sending it to the external signer; nil disables the checks. It is
+// safe to call while the signer is in use.
+func (s *ExternalSigner) SetExpiryPolicy(policy *ExpiryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiry = policy
+}
+
+// CheckSigning applies the policy to a message about to be signed: its
+// deadlines must be in [now, now+MaxTTL]
+func (p *ExpiryPolicy) CheckSigning(message Message) error {
+	return p.check(message, false)
+}
+
+// Check implements Policy with CheckSigning, so an expiry policy can be
+// combined with other policies
+func (p *ExpiryPolicy) Check(data TypedData) error {
+	return p.CheckSigning(data.Message)
+}
+
+// CheckVerified applies the policy to a message whose signature has been
+// verified: its deadlines must be in [now, now+MaxTTL] and its ValidAfter
+// times must have passed
+func (p *ExpiryPolicy) CheckVerified(message Message) error {
+	return p.check(message, true)
+}
+
+// Verify verifies sig like VerifySignatureFast and then checks the message
+// with CheckVerified. A valid signature over an expired message returns
+// false and the policy error.
+func (p *ExpiryPolicy) Verify(
+	sig *Signature,
+	expectedSigner common.Address,
+	domain Domain,
+	types map[string][]Type,
+	primaryType string,
+	message Message,
+) (bool, error) {
+	valid, err := VerifySignatureFast(sig, expectedSigner, domain, types, primaryType, message)
+	if err != nil || !valid {
+		return false, err
+	}
+	if err := p.CheckVerified(message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *ExpiryPolicy) check(message Message, verified bool) error {
+	clock := p.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now()
+
+	for _, field := range p.Deadlines {
+		deadline, ok, err := p.timestamp(message, field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if now.Sub(deadline) > p.Leeway {
+			return fmt.Errorf("%w: %s was %s", ErrExpired, field, deadline.UTC().Format(time.RFC3339))
+		}
+		if p.MaxTTL > 0 && deadline.Sub(now) > p.MaxTTL+p.Leeway {
+			return fmt.Errorf("%w: %s is %s, more than %s away", ErrDeadlineTooFar, field, deadline.UTC().Format(time.RFC3339), p.MaxTTL)
+		}
+	}
+
+	if !verified {
+		return nil
+	}
+	for _, field := range p.ValidAfter {
+		start, ok, err := p.timestamp(message, field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if start.Sub(now) > p.Leeway {
+			return fmt.Errorf("%w: %s is %s", ErrNotYetValid, field, start.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// maxUnixTime is the largest timestamp a time.Time comparison is done on;
+// later values, such as a uint256 max "no deadline", are clamped to it
+var maxUnixTime = big.NewInt(1 << 62 / int64(time.Second))
+
+// timestamp reads a unix-timestamp field. ok is false when the field is
+// missing and the policy does not require it.
+func (p *ExpiryPolicy) timestamp(message Message, field string) (time.Time, bool, error) {
+	value, found := lookupField(message, field)
+	if !found {
+		if p.Required {
+			return time.Time{}, false, fmt.Errorf("message has no %s field", field)
+		}
+		return time.Time{}, false, nil
+	}
+
+	n, err := messageInt(value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if n.Sign() < 0 {
+		return time.Time{}, false, fmt.Errorf("invalid %s: negative timestamp", field)
+	}
+	if n.Cmp(maxUnixTime) > 0 {
+		n = maxUnixTime
+	}
+	return time.Unix(n.Int64(), 0), true, nil
+}
+
+// lookupField resolves a dotted path in nested message maps
+func lookupField(message Message, path string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(message)
+	for _, name := range strings.Split(path, ".") {
+		var fields map[string]interface{}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			fields = v
+		case Message:
+			fields = v
+		default:
+			return nil, false
+		}
+		value, ok := fields[name]
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
+
+// messageInt converts an integer message value, including the plain Go
+// integer types toBigInt does not accept
+func messageInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case uint:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	default:
+		return toBigInt(value)
+	}
+}
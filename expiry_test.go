@@ -0,0 +1,194 @@
+package eip712
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock is a Clock tests move by hand
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time { return c.now }
+
+func (c *fixedClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestExpiryPolicyCheckSigning(t *testing.T) {
+	clock := &fixedClock{time.Unix(1750000000, 0)}
+	policy := DefaultExpiryPolicy(time.Hour)
+	policy.Clock = clock
+	now := clock.now.Unix()
+
+	tests := []struct {
+		name    string
+		message Message
+		wantErr error
+	}{
+		{"within TTL", Message{"deadline": big.NewInt(now + 600)}, nil},
+		{"exactly now", Message{"deadline": now}, nil},
+		{"at max TTL", Message{"deadline": big.NewInt(now + 3600).String()}, nil},
+		{"past", Message{"deadline": big.NewInt(now - 1)}, ErrExpired},
+		{"beyond TTL", Message{"deadline": uint64(now + 3601)}, ErrDeadlineTooFar},
+		{"uint256 max", Message{"deadline": math.MaxBig256}, ErrDeadlineTooFar},
+		{"EIP-3009 window", Message{"validAfter": now + 60, "validBefore": now + 120}, nil},
+		{"EIP-3009 expired", Message{"validAfter": now - 120, "validBefore": now - 60}, ErrExpired},
+		{"later field checked when earlier missing", Message{"endTime": now - 1}, ErrExpired},
+		{"no timestamp fields", Message{"value": "1"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckSigning(tt.message)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpiryPolicyOptions(t *testing.T) {
+	clock := &fixedClock{time.Unix(1750000000, 0)}
+	now := clock.now.Unix()
+
+	t.Run("leeway", func(t *testing.T) {
+		policy := &ExpiryPolicy{Deadlines: []string{"deadline"}, MaxTTL: time.Hour, Leeway: time.Minute, Clock: clock}
+		assert.NoError(t, policy.CheckSigning(Message{"deadline": now - 30}))
+		assert.NoError(t, policy.CheckSigning(Message{"deadline": now + 3630}))
+		assert.ErrorIs(t, policy.CheckSigning(Message{"deadline": now - 90}), ErrExpired)
+	})
+
+	t.Run("no max TTL", func(t *testing.T) {
+		policy := DefaultExpiryPolicy(0)
+		policy.Clock = clock
+		assert.NoError(t, policy.CheckSigning(Message{"deadline": math.MaxBig256}))
+	})
+
+	t.Run("required", func(t *testing.T) {
+		policy := &ExpiryPolicy{Deadlines: []string{"deadline"}, Required: true, Clock: clock}
+		assert.Error(t, policy.CheckSigning(Message{"value": "1"}))
+	})
+
+	t.Run("nested field", func(t *testing.T) {
+		policy := &ExpiryPolicy{Deadlines: []string{"info.deadline"}, Clock: clock}
+		message := Message{"info": map[string]interface{}{"deadline": big.NewInt(now - 1)}}
+		assert.ErrorIs(t, policy.CheckSigning(message), ErrExpired)
+		assert.NoError(t, policy.CheckSigning(Message{"info": "not a struct"}))
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		policy := &ExpiryPolicy{Deadlines: []string{"deadline"}, Clock: clock}
+		assert.Error(t, policy.CheckSigning(Message{"deadline": "soon"}))
+		assert.Error(t, policy.CheckSigning(Message{"deadline": -5}))
+	})
+
+	t.Run("system clock", func(t *testing.T) {
+		policy := &ExpiryPolicy{Deadlines: []string{"deadline"}}
+		assert.NoError(t, policy.CheckSigning(Message{"deadline": time.Now().Add(time.Minute).Unix()}))
+	})
+}
+
+func TestSignerExpiryPolicy(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	clock := &fixedClock{time.Unix(1750000000, 0)}
+	policy := DefaultExpiryPolicy(24 * time.Hour)
+	policy.Clock = clock
+	signer.SetExpiryPolicy(policy)
+
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	spender := common.HexToAddress(testAddress2)
+	value := big.NewInt(1000000)
+
+	_, err = signer.SignPermit(token, "USD Coin", "2", spender, value, big.NewInt(0), big.NewInt(clock.now.Unix()+3600))
+	assert.NoError(t, err)
+	_, err = signer.SignPermit(token, "USD Coin", "2", spender, value, big.NewInt(0), big.NewInt(clock.now.Unix()-3600))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	t.Run("seaport", func(t *testing.T) {
+		// The test order ends at 1800000000, more than a day away
+		order := createTestSeaportOrder(1)
+		_, err := signer.SignSeaportOrder(order, SeaportV16Address)
+		assert.ErrorIs(t, err, ErrDeadlineTooFar)
+
+		bulk, err := NewSeaportBulkOrder(SeaportDomain(signer.ChainID(), SeaportV16Address), []*SeaportOrder{order})
+		require.NoError(t, err)
+		_, err = signer.SignSeaportBulkOrder(bulk)
+		assert.ErrorIs(t, err, ErrDeadlineTooFar)
+	})
+
+	t.Run("pool and merkle batch", func(t *testing.T) {
+		items := createPermitBatch(signer.Address(), 2)
+		items[0].Message["deadline"] = big.NewInt(clock.now.Unix() + 60).String()
+		items[1].Message["deadline"] = big.NewInt(clock.now.Unix() - 1).String()
+
+		_, err := NewSignerPool(signer, 1).SignBatch(context.Background(), items)
+		assert.ErrorIs(t, err, ErrExpired)
+		_, err = signer.SignMerkleBatch(context.Background(), createTestDomain("Settlement", "1", 1), items)
+		assert.ErrorIs(t, err, ErrExpired)
+	})
+
+	signer.SetExpiryPolicy(nil)
+	_, err = signer.SignPermit(token, "USD Coin", "2", spender, value, big.NewInt(0), big.NewInt(clock.now.Unix()-3600))
+	assert.NoError(t, err)
+}
+
+func TestExpiryPolicyVerify(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	clock := &fixedClock{time.Unix(1750000000, 0)}
+	policy := DefaultExpiryPolicy(time.Hour)
+	policy.Clock = clock
+	signer.SetExpiryPolicy(policy)
+
+	domain := createTestDomainWithContract("USD Coin", "2", 1, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	types := map[string][]Type{
+		"TransferWithAuthorization": {
+			{Name: "from", Type: "address"},
+			{Name: "to", Type: "address"},
+			{Name: "value", Type: "uint256"},
+			{Name: "validAfter", Type: "uint256"},
+			{Name: "validBefore", Type: "uint256"},
+			{Name: "nonce", Type: "bytes32"},
+		},
+	}
+	message := Message{
+		"from":        testAddress1,
+		"to":          testAddress2,
+		"value":       "1000000",
+		"validAfter":  big.NewInt(clock.now.Unix() + 600).String(),
+		"validBefore": big.NewInt(clock.now.Unix() + 1800).String(),
+		"nonce":       common.HexToHash("0x01").Hex(),
+	}
+
+	// Signing ahead of validAfter is allowed
+	sig, err := signer.SignTypedData(domain, types, "TransferWithAuthorization", message)
+	require.NoError(t, err)
+
+	valid, err := policy.Verify(sig, signer.Address(), domain, types, "TransferWithAuthorization", message)
+	assert.ErrorIs(t, err, ErrNotYetValid)
+	assert.False(t, valid)
+
+	clock.advance(15 * time.Minute)
+	valid, err = policy.Verify(sig, signer.Address(), domain, types, "TransferWithAuthorization", message)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = policy.Verify(sig, common.HexToAddress(testAddress2), domain, types, "TransferWithAuthorization", message)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	clock.advance(30 * time.Minute)
+	valid, err = policy.Verify(sig, signer.Address(), domain, types, "TransferWithAuthorization", message)
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.False(t, valid)
+}
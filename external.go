@@ -0,0 +1,265 @@
+package eip712
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// TypedDataSigner signs EIP-712 typed data. *Signer and *ExternalSigner both
+// implement it, so application code can switch between in-process keys and
+// an external signer.
+type TypedDataSigner interface {
+	Address() common.Address
+	SignTypedData(domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error)
+}
+
+var (
+	_ TypedDataSigner = (*Signer)(nil)
+	_ TypedDataSigner = (*ExternalSigner)(nil)
+)
+
+// ExternalSigner forwards typed data to an external signer such as Clef
+// through its account_signTypedData JSON-RPC method. The signer typically
+// asks its operator to approve each request.
+type ExternalSigner struct {
+	client       *rpc.Client
+	address      common.Address
+	chainID      *big.Int
+	mu           sync.RWMutex
+	audit        AuditSink
+	expiry       *ExpiryPolicy
+	policy       Policy
+	enforceChain bool
+	closed       bool
+	// Timeout bounds each signing request, including operator approval;
+	// zero means no timeout
+	Timeout time.Duration
+}
+
+// NewExternalSigner connects to an external signer at endpoint, an IPC path
+// or an HTTP(S) or WebSocket URL, and signs as account
+//
+// Example:
+//
+//	clef, err := NewExternalSigner(ctx, "/home/op/.clef/clef.ipc", account, 1)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer clef.Close()
+//
+//	sig, err := clef.SignTypedData(domain, types, "Mail", message)
+func NewExternalSigner(ctx context.Context, endpoint string, account common.Address, chainID int64) (*ExternalSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to external signer: %w", err)
+	}
+	return NewExternalSignerWithClient(client, account, chainID), nil
+}
+
+// NewExternalSignerWithClient returns an external signer using an existing
+// RPC client
+func NewExternalSignerWithClient(client *rpc.Client, account common.Address, chainID int64) *ExternalSigner {
+	return &ExternalSigner{
+		client:  client,
+		address: account,
+		chainID: big.NewInt(chainID),
+	}
+}
+
+// Address returns the account the external signer signs with
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
+
+// ChainID returns the chain ID used for signing
+func (s *ExternalSigner) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
+// Close closes the connection to the external signer. Signing afterwards
+// returns ErrSignerClosed; Address and ChainID keep working. Close may be
+// called more than once.
+func (s *ExternalSigner) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.client.Close()
+	return nil
+}
+
+// isClosed reports whether Close has been called
+func (s *ExternalSigner) isClosed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.closed
+}
+
+// SignTypedData sends the typed data to the external signer. The returned
+// signature is checked against the locally computed digest and account.
+func (s *ExternalSigner) SignTypedData(domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	return s.SignTypedDataContext(context.Background(), domain, types, primaryType, message)
+}
+
+func (s *ExternalSigner) signTypedData(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	if s.isClosed() {
+		return nil, ErrSignerClosed
+	}
+	if err := s.checkPolicies(TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}); err != nil {
+		return nil, err
+	}
+	if err := validateNoCycles(types); err != nil {
+		return nil, err
+	}
+
+	hash, err := typedDataHash(domain, types, primaryType, message, buildDomainTypesStatic(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	var signature hexutil.Bytes
+	err = s.client.CallContext(ctx, &signature, "account_signTypedData",
+		common.NewMixedcaseAddress(s.address), externalTypedData(domain, types, primaryType, message))
+	if err != nil {
+		// A request cut off by Close fails in the transport
+		if s.isClosed() {
+			return nil, ErrSignerClosed
+		}
+		return nil, fmt.Errorf("external signer failed: %w", err)
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("external signer returned %d-byte signature, want 65", len(signature))
+	}
+	if signature[64] < 27 {
+		signature[64] += 27
+	}
+
+	recovered, err := recoverHash(hash, signature.String())
+	if err != nil {
+		return nil, fmt.Errorf("external signer returned invalid signature: %w", err)
+	}
+	if recovered != s.address {
+		return nil, fmt.Errorf("external signer signed with %s, want %s", recovered.Hex(), s.address.Hex())
+	}
+
+	sig := newSignature(hash, signature)
+	data := TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}
+	s.mu.RLock()
+	sink := s.audit
+	s.mu.RUnlock()
+	if err := recordAudit(sink, s.address, data, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// externalTypedData converts typed data to the JSON form external signers
+// accept: the EIP712Domain type is always present and message values are
+// JSON-safe
+func externalTypedData(domain Domain, types map[string][]Type, primaryType string, message Message) apitypes.TypedData {
+	typedData := apitypes.TypedData{
+		Types:       make(apitypes.Types, len(types)+1),
+		PrimaryType: primaryType,
+		Domain:      domainToAPITypesStatic(domain),
+		Message:     jsonSafeValue(map[string]interface{}(message)).(map[string]interface{}),
+	}
+
+	for typeName, fields := range types {
+		apiTypes := make([]apitypes.Type, len(fields))
+		for i, field := range fields {
+			apiTypes[i] = apitypes.Type{Name: field.Name, Type: field.Type}
+		}
+		typedData.Types[typeName] = apiTypes
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		typedData.Types["EIP712Domain"] = buildDomainTypesStatic(domain)
+	}
+
+	return typedData
+}
+
+// jsonSafeValue converts message values whose default JSON encoding an
+// external signer would misread: *big.Int becomes a decimal string rather
+// than a lossy number, []byte and fixed-size byte arrays a hex string
+// rather than base64 or a list of numbers. Typed slices, arrays and maps,
+// such as []common.Hash or []Message, are converted element by element.
+func jsonSafeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = jsonSafeValue(item)
+		}
+		return out
+	case Message:
+		return jsonSafeValue(map[string]interface{}(v))
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = jsonSafeValue(item)
+		}
+		return out
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			raw := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(raw), rv)
+			return hexutil.Encode(raw)
+		}
+		return jsonSafeElements(rv)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return value
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return hexutil.Encode(rv.Bytes())
+		}
+		return jsonSafeElements(rv)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = jsonSafeValue(iter.Value().Interface())
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// jsonSafeElements converts each element of a slice or array
+func jsonSafeElements(rv reflect.Value) []interface{} {
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = jsonSafeValue(rv.Index(i).Interface())
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClef implements account_signTypedData the way Clef does, with a local
+// key and no operator prompt
+type stubClef struct {
+	signer   *Signer
+	reject   bool
+	requests []apitypes.TypedData
+}
+
+func (c *stubClef) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (hexutil.Bytes, error) {
+	c.requests = append(c.requests, data)
+	if c.reject {
+		return nil, errors.New("request denied")
+	}
+	if addr.Address() != c.signer.Address() {
+		return nil, errors.New("unknown account")
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := c.signer.key.sign(hash)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Decode(sig.Bytes)
+}
+
+func newStubClef(t *testing.T, privateKey string) (*stubClef, string) {
+	signer, err := NewSigner(privateKey, 1)
+	require.NoError(t, err)
+
+	clef := &stubClef{signer: signer}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", clef))
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return clef, httpServer.URL
+}
+
+func TestExternalSigner(t *testing.T) {
+	clef, url := newStubClef(t, testPrivateKey1)
+
+	external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+	require.NoError(t, err)
+	defer external.Close()
+
+	local, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomainWithContract("Test", "1", 1, testAddress2)
+	types := map[string][]Type{
+		"Order": {
+			{Name: "maker", Type: "address"},
+			{Name: "amount", Type: "uint256"},
+			{Name: "data", Type: "bytes"},
+			{Name: "salt", Type: "bytes32"},
+		},
+	}
+	message := Message{
+		"maker":  testAddress1,
+		"amount": new(big.Int).Lsh(big.NewInt(1), 200),
+		"data":   []byte{0xde, 0xad, 0xbe, 0xef},
+		"salt":   [32]byte{1, 2, 3},
+	}
+
+	// The same code signs through either implementation
+	for _, signer := range []TypedDataSigner{local, external} {
+		sig, err := signer.SignTypedData(domain, types, "Order", message)
+		require.NoError(t, err)
+
+		valid, err := VerifySignature(sig, common.HexToAddress(testAddress1), domain, types, "Order", message)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	require.Len(t, clef.requests, 1)
+	request := clef.requests[0]
+	assert.Equal(t, "Order", request.PrimaryType)
+	assert.Len(t, request.Types["EIP712Domain"], 4)
+	assert.Equal(t, new(big.Int).Lsh(big.NewInt(1), 200).String(), request.Message["amount"])
+	assert.Equal(t, "0xdeadbeef", request.Message["data"])
+}
+
+func TestExternalSignerErrors(t *testing.T) {
+	clef, url := newStubClef(t, testPrivateKey1)
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	t.Run("rejected", func(t *testing.T) {
+		clef.reject = true
+		defer func() { clef.reject = false }()
+
+		external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+		require.NoError(t, err)
+		_, err = external.SignTypedData(domain, types, "Mail", message)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "request denied")
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress2), 1)
+		require.NoError(t, err)
+		_, err = external.SignTypedData(domain, types, "Mail", message)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		// The stub signs with key 2 but claims account 1
+		other, err := NewSigner(testPrivateKey2, 1)
+		require.NoError(t, err)
+		clef.signer = &Signer{key: other.key, address: common.HexToAddress(testAddress1), chainID: big.NewInt(1)}
+		defer func() { clef.signer, _ = NewSigner(testPrivateKey1, 1) }()
+
+		external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+		require.NoError(t, err)
+		_, err = external.SignTypedData(domain, types, "Mail", message)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "external signer signed with")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+		require.NoError(t, err)
+		require.NoError(t, external.Close())
+		require.NoError(t, external.Close())
+
+		_, err = external.SignTypedData(domain, types, "Mail", message)
+		assert.ErrorIs(t, err, ErrSignerClosed)
+		_, err = external.SignTypedDataContext(context.Background(), domain, types, "Mail", message)
+		assert.ErrorIs(t, err, ErrSignerClosed)
+		assert.Equal(t, common.HexToAddress(testAddress1), external.Address())
+	})
+
+	t.Run("cyclic types", func(t *testing.T) {
+		external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+		require.NoError(t, err)
+		cyclic := map[string][]Type{"A": {{Name: "a", Type: "A"}}}
+		_, err = external.SignTypedData(domain, cyclic, "A", Message{})
+		assert.Error(t, err)
+	})
+}
+
+func TestExternalSignerPolicies(t *testing.T) {
+	clef, url := newStubClef(t, testPrivateKey1)
+	external, err := NewExternalSigner(context.Background(), url, common.HexToAddress(testAddress1), 1)
+	require.NoError(t, err)
+	defer external.Close()
+
+	clock := &fixedClock{time.Unix(1750000000, 0)}
+	permit := func(chainID int64, deadline int64) TypedData {
+		data := createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0))
+		data.Domain.ChainID = big.NewInt(chainID)
+		data.Message["deadline"] = big.NewInt(deadline)
+		return data
+	}
+	sign := func(data TypedData) error {
+		_, err := external.SignTypedData(data.Domain, data.Types, data.PrimaryType, data.Message)
+		return err
+	}
+	valid := clock.now.Unix() + 3600
+
+	external.SetEnforceChain(true)
+	assert.ErrorIs(t, sign(permit(137, valid)), ErrChainMismatch)
+	external.SetEnforceChain(false)
+
+	expiry := DefaultExpiryPolicy(24 * time.Hour)
+	expiry.Clock = clock
+	external.SetExpiryPolicy(expiry)
+	assert.ErrorIs(t, sign(permit(1, clock.now.Unix()-3600)), ErrExpired)
+	external.SetExpiryPolicy(nil)
+
+	external.SetPolicy(AllowChainIDs(1))
+	var denial *PolicyDenial
+	assert.ErrorAs(t, sign(permit(10, valid)), &denial)
+
+	// Refused requests never reach the external signer
+	assert.Empty(t, clef.requests)
+	require.NoError(t, sign(permit(1, valid)))
+	assert.Len(t, clef.requests, 1)
+}
+
+func TestJSONSafeValue(t *testing.T) {
+	value := jsonSafeValue(map[string]interface{}{
+		"n":    big.NewInt(42),
+		"b":    []byte{1},
+		"list": []interface{}{big.NewInt(1), Message{"h": common.Hash{}}},
+		"s":    "unchanged",
+	}).(map[string]interface{})
+
+	assert.Equal(t, "42", value["n"])
+	assert.Equal(t, "0x01", value["b"])
+	assert.Equal(t, "1", value["list"].([]interface{})[0])
+	assert.Equal(t, common.Hash{}.Hex(), value["list"].([]interface{})[1].(map[string]interface{})["h"])
+	assert.Equal(t, "unchanged", value["s"])
+
+	t.Run("typed values", func(t *testing.T) {
+		hash := common.HexToHash("0x01")
+		value := jsonSafeValue(Message{
+			"b4":        [4]byte{0xde, 0xad, 0xbe, 0xef},
+			"b32":       [32]byte{1},
+			"hashes":    []common.Hash{hash},
+			"nested":    [][]common.Hash{{hash}},
+			"addresses": []common.Address{common.HexToAddress(testAddress1)},
+			"amounts":   []*big.Int{big.NewInt(7)},
+			"messages":  []Message{{"n": big.NewInt(1)}},
+			"items":     map[string]Message{"a": {"b": []byte{2}}},
+		}).(map[string]interface{})
+
+		assert.Equal(t, "0xdeadbeef", value["b4"])
+		assert.Equal(t, hexutil.Encode([]byte{1, 31: 0}), value["b32"])
+		assert.Equal(t, []interface{}{hash.Hex()}, value["hashes"])
+		assert.Equal(t, []interface{}{[]interface{}{hash.Hex()}}, value["nested"])
+		assert.Equal(t, []interface{}{common.HexToAddress(testAddress1).Hex()}, value["addresses"])
+		assert.Equal(t, []interface{}{"7"}, value["amounts"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"n": "1"}}, value["messages"])
+		assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": "0x02"}}, value["items"])
+	})
+}
@@ -0,0 +1,34 @@
+//go:build !race
+// +build !race
+
+package eip712
+
+import "testing"
+
+// maxCachedHashAllocs bounds the allocations of hashing a simple message once
+// its schema is cached
+const maxCachedHashAllocs = 34
+
+// TestFastEncoderCachedAllocs guards the fast path: a types map already
+// cached is neither copied nor hashed again
+func TestFastEncoderCachedAllocs(t *testing.T) {
+	domain := createTestDomain("Allocs Test", "1", 1)
+	types := map[string][]Type{"Message": {{Name: "content", Type: "string"}}}
+	message := Message{"content": "Hello, World!"}
+	if _, err := NewFastTypedDataEncoder(domain, types, "Message", message).Hash(); err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		globalEncoderCache.prepare(types, domain.FieldSet())
+	}); allocs != 0 {
+		t.Errorf("Preparing a cached schema allocates %v times, want 0", allocs)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		NewFastTypedDataEncoder(domain, types, "Message", message).Hash()
+	})
+	if allocs > maxCachedHashAllocs {
+		t.Errorf("Hashing with a cached schema allocates %v times, want at most %d", allocs, maxCachedHashAllocs)
+	}
+}
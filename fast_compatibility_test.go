@@ -227,4 +227,79 @@ func TestFastPermit(t *testing.T) {
 	if originalSig.Hash != fastSig.Hash {
 		t.Errorf("Permit hash mismatch")
 	}
+}
+
+// TestFastEncoderCacheBounded tests that caller-supplied schemas cannot grow
+// the encoder cache without limit, and that the least recently used schema
+// is the one evicted
+func TestFastEncoderCacheBounded(t *testing.T) {
+	cache := newEncoderCache(2)
+	domain := Domain{Name: "Test", Version: "1", ChainID: big.NewInt(1)}
+	schema := func(i int) (map[string][]Type, Message) {
+		field := "field" + big.NewInt(int64(i)).String()
+		return map[string][]Type{"Item": {{Name: field, Type: "uint256"}}}, Message{field: big.NewInt(int64(i))}
+	}
+	hash := func(types map[string][]Type, message Message) []byte {
+		encoder := NewFastTypedDataEncoder(domain, types, "Item", message)
+		encoder.cache = cache
+		h, err := encoder.Hash()
+		if err != nil {
+			t.Fatalf("Hash failed: %v", err)
+		}
+		return h
+	}
+	
+	hot, hotMessage := schema(0)
+	want, err := NewFastTypedDataEncoder(domain, hot, "Item", hotMessage).Hash()
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	hash(hot, hotMessage)
+	hotSchema := cache.prepare(hot, domain.FieldSet())
+	
+	for i := 1; i < 10; i++ {
+		hash(schema(i))
+		// Keep the hot schema recently used
+		hash(hot, hotMessage)
+	}
+	if len(cache.refs) != 2 || len(cache.schemas) != 2 || cache.recent.Len() != 2 {
+		t.Errorf("Cache holds %d maps and %d schemas, want 2", len(cache.refs), len(cache.schemas))
+	}
+	if cache.prepare(hot, domain.FieldSet()) != hotSchema {
+		t.Error("Hot schema was evicted")
+	}
+	
+	// Equal maps share type data, and an evicted map is prepared again
+	copied, _ := schema(0)
+	for i := 1; i < 10; i++ {
+		hash(schema(i))
+	}
+	if got := hash(copied, hotMessage); string(got) != string(want) {
+		t.Errorf("Hash after eviction = %x, want %x", got, want)
+	}
+}
+
+// TestFastEncoderCacheStale tests that a types map changed after it was
+// cached is prepared again
+func TestFastEncoderCacheStale(t *testing.T) {
+	domain := Domain{Name: "Test", Version: "1", ChainID: big.NewInt(1)}
+	types := map[string][]Type{"Item": {{Name: "a", Type: "uint256"}}}
+	message := Message{"a": big.NewInt(1), "b": big.NewInt(2)}
+	before, err := NewFastTypedDataEncoder(domain, types, "Item", message).Hash()
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	
+	types["Item"] = append(types["Item"], Type{Name: "b", Type: "uint256"})
+	after, err := NewFastTypedDataEncoder(domain, types, "Item", message).Hash()
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	fresh, err := NewFastTypedDataEncoder(domain, map[string][]Type{"Item": types["Item"]}, "Item", message).Hash()
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if string(after) == string(before) || string(after) != string(fresh) {
+		t.Errorf("Hash of changed types = %x, want %x", after, fresh)
+	}
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package eip712
 
 import (
 	"bytes"
+	"container/list"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -18,17 +20,155 @@ import (
 )
 
 // Cache structures for performance
+
+// maxCachedSchemas bounds the schemas globalEncoderCache holds, since
+// schemas may be supplied by callers
+const maxCachedSchemas = 1024
+
+// encoderCache prepares types maps and holds the type data derived from
+// them. A map is found by its identity, so reusing one across encoders
+// neither copies nor hashes it again; a map seen for the first time is
+// identified by its content, so equal maps share type data. Once limit
+// maps are cached, the least recently used is evicted.
 type encoderCache struct {
+	mu      sync.Mutex
+	limit   int
+	refs    map[schemaRef]*preparedSchema
+	schemas map[schemaID]*schemaCache
+	// recent orders the prepared schemas, most recently used first
+	recent *list.List
+}
+
+// schemaRef identifies the inputs of a preparedSchema: the identity of the
+// caller's types map and the domain fields its EIP712Domain is built from
+type schemaRef struct {
+	types  uintptr
+	fields DomainFields
+}
+
+// schemaID identifies a full set of type definitions by content
+type schemaID [32]byte
+
+// preparedSchema is a validated types map completed with its EIP712Domain
+// definition, along with the type data derived from it
+type preparedSchema struct {
+	// source is the caller's map, kept so its identity is not reused
+	source map[string][]Type
+	types  map[string][]Type
+	ref    schemaRef
+	id     schemaID
+	cache  *schemaCache
+	err    error
+	elem   *list.Element
+}
+
+// schemaCache is the derived type data of one schema, by type name
+type schemaCache struct {
 	mu           sync.RWMutex
 	typeHashes   map[string][]byte
 	encodedTypes map[string]string
 	dependencies map[string][]string
+	// refs counts the cached preparedSchemas using it, under encoderCache.mu
+	refs int
+}
+
+var globalEncoderCache = newEncoderCache(maxCachedSchemas)
+
+func newEncoderCache(limit int) *encoderCache {
+	return &encoderCache{
+		limit:   limit,
+		refs:    make(map[schemaRef]*preparedSchema),
+		schemas: make(map[schemaID]*schemaCache),
+		recent:  list.New(),
+	}
+}
+
+// prepare returns the prepared form of types, whose EIP712Domain, unless
+// defined, is built from fields
+func (c *encoderCache) prepare(types map[string][]Type, fields DomainFields) *preparedSchema {
+	ref := schemaRef{types: reflect.ValueOf(types).Pointer(), fields: fields}
+	
+	c.mu.Lock()
+	if schema, ok := c.refs[ref]; ok && schema.current() {
+		c.recent.MoveToFront(schema.elem)
+		c.mu.Unlock()
+		return schema
+	}
+	c.mu.Unlock()
+	
+	schema := newPreparedSchema(types, ref)
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stale, ok := c.refs[ref]; ok {
+		c.remove(stale)
+	}
+	cache, ok := c.schemas[schema.id]
+	if !ok {
+		cache = &schemaCache{
+			typeHashes:   make(map[string][]byte),
+			encodedTypes: make(map[string]string),
+			dependencies: make(map[string][]string),
+		}
+		c.schemas[schema.id] = cache
+	}
+	cache.refs++
+	schema.cache = cache
+	schema.elem = c.recent.PushFront(schema)
+	c.refs[ref] = schema
+	for c.recent.Len() > c.limit {
+		c.remove(c.recent.Back().Value.(*preparedSchema))
+	}
+	return schema
+}
+
+// remove evicts a prepared schema, and its type data once unused
+func (c *encoderCache) remove(schema *preparedSchema) {
+	c.recent.Remove(schema.elem)
+	delete(c.refs, schema.ref)
+	schema.cache.refs--
+	if schema.cache.refs == 0 {
+		delete(c.schemas, schema.id)
+	}
 }
 
-var globalEncoderCache = &encoderCache{
-	typeHashes:   make(map[string][]byte),
-	encodedTypes: make(map[string]string),
-	dependencies: make(map[string][]string),
+// newPreparedSchema validates types and completes them with an
+// EIP712Domain definition, without mutating the caller's map
+func newPreparedSchema(types map[string][]Type, ref schemaRef) *preparedSchema {
+	schema := &preparedSchema{source: types, types: types, ref: ref}
+	schema.err = validateNoCycles(types)
+	if _, ok := types["EIP712Domain"]; !ok {
+		schema.types = make(map[string][]Type, len(types)+1)
+		for name, fields := range types {
+			schema.types[name] = fields
+		}
+		schema.types["EIP712Domain"] = ref.fields.Types()
+	}
+	schema.id = newSchemaID(schema.types)
+	return schema
+}
+
+// current reports whether the caller's map still holds the type
+// definitions it was prepared from. Replacing, adding or removing a type
+// is detected; editing a field of a definition in place is not.
+func (s *preparedSchema) current() bool {
+	n := len(s.source)
+	if _, ok := s.source["EIP712Domain"]; !ok {
+		n++
+	}
+	if n != len(s.types) {
+		return false
+	}
+	for name, fields := range s.source {
+		prepared, ok := s.types[name]
+		if !ok || len(prepared) != len(fields) {
+			return false
+		}
+		if len(fields) > 0 && &prepared[0] != &fields[0] {
+			return false
+		}
+	}
+	return true
 }
 
 // Buffer pool to reduce allocations
@@ -45,8 +185,18 @@ type FastTypedDataEncoder struct {
 	Domain      Domain
 	Message     Message
 	cache       *encoderCache
+	// schema is the prepared form of Types, looked up once per schema
+	schema *preparedSchema
+	// prepared is set when Types and schema were completed in advance by
+	// usePrepared, letting prepare skip the lookup
+	prepared bool
+	ctx      context.Context
 }
 
+// arrayContextCheckInterval is how many array elements are encoded between
+// checks for cancellation
+const arrayContextCheckInterval = 64
+
 // NewFastTypedDataEncoder creates a new optimized encoder
 func NewFastTypedDataEncoder(domain Domain, types map[string][]Type, primaryType string, message Message) *FastTypedDataEncoder {
 	return &FastTypedDataEncoder{
@@ -58,22 +208,31 @@ func NewFastTypedDataEncoder(domain Domain, types map[string][]Type, primaryType
 	}
 }
 
+// reset points the encoder at new typed data so it can be reused
+func (e *FastTypedDataEncoder) reset(domain Domain, types map[string][]Type, primaryType string, message Message) {
+	e.Types = types
+	e.PrimaryType = primaryType
+	e.Domain = domain
+	e.Message = message
+	e.schema = nil
+	e.prepared = false
+	e.ctx = nil
+}
+
+// usePrepared installs types already validated and completed with an
+// EIP712Domain definition
+func (e *FastTypedDataEncoder) usePrepared(schema *preparedSchema) {
+	e.Types = schema.types
+	e.schema = schema
+	e.prepared = true
+}
+
 // Hash computes the EIP-712 hash of the typed data
 func (e *FastTypedDataEncoder) Hash() ([]byte, error) {
-	// Validate types
-	if err := e.validate(); err != nil {
-		return nil, err
-	}
-	
-	// Build domain types if not present
-	if _, ok := e.Types["EIP712Domain"]; !ok {
-		e.Types["EIP712Domain"] = e.buildDomainTypes()
-	}
-	
 	// Hash domain
-	domainSeparator, err := e.hashStruct("EIP712Domain", e.domainToMap())
+	domainSeparator, err := e.DomainSeparator()
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash domain: %w", err)
+		return nil, err
 	}
 	
 	// Hash message
@@ -90,6 +249,64 @@ func (e *FastTypedDataEncoder) Hash() ([]byte, error) {
 	return crypto.Keccak256(rawData), nil
 }
 
+// HashContext computes the EIP-712 hash like Hash, checking ctx for
+// cancellation while encoding arrays so that huge messages can be abandoned
+func (e *FastTypedDataEncoder) HashContext(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e.ctx = ctx
+	defer func() { e.ctx = nil }()
+	return e.Hash()
+}
+
+// DomainSeparator computes the hash of the encoder's EIP712Domain
+func (e *FastTypedDataEncoder) DomainSeparator() ([]byte, error) {
+	if err := e.prepare(); err != nil {
+		return nil, err
+	}
+	
+	domainData, err := e.domainToMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	
+	domainSeparator, err := e.hashStruct("EIP712Domain", domainData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	return domainSeparator, nil
+}
+
+// HashStruct computes the EIP-712 hashStruct of data encoded as typeName
+func (e *FastTypedDataEncoder) HashStruct(typeName string, data map[string]interface{}) ([]byte, error) {
+	if err := e.prepare(); err != nil {
+		return nil, err
+	}
+	return e.hashStruct(typeName, data)
+}
+
+// prepare validates the types and completes them with an EIP712Domain
+// definition, without mutating the caller's map
+func (e *FastTypedDataEncoder) prepare() error {
+	if e.prepared {
+		return nil
+	}
+	if e.schema == nil || !sameTypes(e.Types, e.schema.types) || e.schema.ref.fields != e.Domain.FieldSet() {
+		e.schema = e.cache.prepare(e.Types, e.Domain.FieldSet())
+	}
+	if e.schema.err != nil {
+		return e.schema.err
+	}
+	e.Types = e.schema.types
+	return nil
+}
+
+// sameTypes reports whether a and b are the same map
+func sameTypes(a, b map[string][]Type) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
 // hashStruct computes the hash of a struct
 func (e *FastTypedDataEncoder) hashStruct(primaryType string, data map[string]interface{}) ([]byte, error) {
 	encoded, err := e.encodeData(primaryType, data)
@@ -143,8 +360,8 @@ func (e *FastTypedDataEncoder) encodeData(primaryType string, data map[string]in
 
 // encodeValue encodes a single value
 func (e *FastTypedDataEncoder) encodeValue(fieldType string, value interface{}) ([]byte, error) {
-	// Handle arrays
-	if strings.HasSuffix(fieldType, "[]") {
+	// Handle arrays, including fixed-size and nested ones
+	if strings.HasSuffix(fieldType, "]") {
 		return e.encodeArray(fieldType, value)
 	}
 	
@@ -160,13 +377,19 @@ func (e *FastTypedDataEncoder) encodeValue(fieldType string, value interface{})
 // encodeArray encodes an array value with optimizations
 func (e *FastTypedDataEncoder) encodeArray(fieldType string, value interface{}) ([]byte, error) {
 	// Get element type
-	elementType := strings.TrimSuffix(fieldType, "[]")
+	elementType, length, _, err := splitArrayType(fieldType)
+	if err != nil {
+		return nil, err
+	}
 	
 	// Convert to slice
 	slice := reflect.ValueOf(value)
-	if slice.Kind() != reflect.Slice {
+	if slice.Kind() != reflect.Slice && slice.Kind() != reflect.Array {
 		return nil, fmt.Errorf("expected slice for array type %s", fieldType)
 	}
+	if length > 0 && slice.Len() != length {
+		return nil, fmt.Errorf("expected %d elements for array type %s, got %d", length, fieldType, slice.Len())
+	}
 	
 	// Pre-allocate buffer for better performance
 	buf := encoderBufferPool.Get().(*bytes.Buffer)
@@ -182,6 +405,11 @@ func (e *FastTypedDataEncoder) encodeArray(fieldType string, value interface{})
 	
 	// Encode each element
 	for i := 0; i < slice.Len(); i++ {
+		if e.ctx != nil && i%arrayContextCheckInterval == 0 {
+			if err := e.ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		elem := slice.Index(i).Interface()
 		
 		// Handle string elements in arrays specially
@@ -308,19 +536,26 @@ func (e *FastTypedDataEncoder) encodeInteger(fieldType string, value interface{}
 		}
 	}
 	
-	// Convert to 32-byte array
-	return math.U256Bytes(n), nil
+	// Convert to 32-byte array, filling it directly when n fits. U256Bytes
+	// truncates in place, so otherwise work on a copy rather than a *big.Int
+	// the caller may share.
+	if n.Sign() >= 0 && n.BitLen() <= 256 {
+		return n.FillBytes(make([]byte, 32)), nil
+	}
+	return math.U256Bytes(new(big.Int).Set(n)), nil
 }
 
 // typeHash returns the cached type hash or computes it
 func (e *FastTypedDataEncoder) typeHash(typeName string) ([]byte, error) {
+	cache := e.typeCache()
+	
 	// Check cache first
-	e.cache.mu.RLock()
-	if hash, ok := e.cache.typeHashes[typeName]; ok {
-		e.cache.mu.RUnlock()
+	cache.mu.RLock()
+	if hash, ok := cache.typeHashes[typeName]; ok {
+		cache.mu.RUnlock()
 		return hash, nil
 	}
-	e.cache.mu.RUnlock()
+	cache.mu.RUnlock()
 	
 	// Compute type hash
 	encoded, err := e.encodeType(typeName)
@@ -331,22 +566,24 @@ func (e *FastTypedDataEncoder) typeHash(typeName string) ([]byte, error) {
 	hash := crypto.Keccak256([]byte(encoded))
 	
 	// Cache the result
-	e.cache.mu.Lock()
-	e.cache.typeHashes[typeName] = hash
-	e.cache.mu.Unlock()
+	cache.mu.Lock()
+	cache.typeHashes[typeName] = hash
+	cache.mu.Unlock()
 	
 	return hash, nil
 }
 
 // encodeType encodes the type definition
 func (e *FastTypedDataEncoder) encodeType(typeName string) (string, error) {
+	cache := e.typeCache()
+	
 	// Check cache first
-	e.cache.mu.RLock()
-	if encoded, ok := e.cache.encodedTypes[typeName]; ok {
-		e.cache.mu.RUnlock()
+	cache.mu.RLock()
+	if encoded, ok := cache.encodedTypes[typeName]; ok {
+		cache.mu.RUnlock()
 		return encoded, nil
 	}
-	e.cache.mu.RUnlock()
+	cache.mu.RUnlock()
 	
 	// Get dependencies
 	deps := e.dependencies(typeName)
@@ -382,22 +619,24 @@ func (e *FastTypedDataEncoder) encodeType(typeName string) (string, error) {
 	encoded := strings.Join(parts, "")
 	
 	// Cache the result
-	e.cache.mu.Lock()
-	e.cache.encodedTypes[typeName] = encoded
-	e.cache.mu.Unlock()
+	cache.mu.Lock()
+	cache.encodedTypes[typeName] = encoded
+	cache.mu.Unlock()
 	
 	return encoded, nil
 }
 
 // dependencies returns sorted dependencies with caching
 func (e *FastTypedDataEncoder) dependencies(typeName string) []string {
+	cache := e.typeCache()
+	
 	// Check cache first
-	e.cache.mu.RLock()
-	if deps, ok := e.cache.dependencies[typeName]; ok {
-		e.cache.mu.RUnlock()
+	cache.mu.RLock()
+	if deps, ok := cache.dependencies[typeName]; ok {
+		cache.mu.RUnlock()
 		return deps
 	}
-	e.cache.mu.RUnlock()
+	cache.mu.RUnlock()
 	
 	// Compute dependencies
 	deps := make(map[string]bool)
@@ -411,13 +650,52 @@ func (e *FastTypedDataEncoder) dependencies(typeName string) []string {
 	sort.Strings(result)
 	
 	// Cache the result
-	e.cache.mu.Lock()
-	e.cache.dependencies[typeName] = result
-	e.cache.mu.Unlock()
+	cache.mu.Lock()
+	cache.dependencies[typeName] = result
+	cache.mu.Unlock()
 	
 	return result
 }
 
+// typeCache returns the type data of the encoder's schema. Type data is
+// scoped to the schema it was defined in, so two schemas reusing a type
+// name (e.g. "EIP712Domain" with different fields) never share it.
+func (e *FastTypedDataEncoder) typeCache() *schemaCache {
+	if e.schema == nil {
+		e.schema = e.cache.prepare(e.Types, e.Domain.FieldSet())
+	}
+	return e.schema.cache
+}
+
+// newSchemaID hashes a deterministic encoding of a full set of type
+// definitions
+func newSchemaID(types map[string][]Type) schemaID {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	hasher := crypto.NewKeccakState()
+	for _, name := range names {
+		hasher.Write([]byte(name))
+		hasher.Write([]byte{'('})
+		for i, field := range types[name] {
+			if i > 0 {
+				hasher.Write([]byte{','})
+			}
+			hasher.Write([]byte(field.Type))
+			hasher.Write([]byte{' '})
+			hasher.Write([]byte(field.Name))
+		}
+		hasher.Write([]byte{')'})
+	}
+	
+	var id schemaID
+	hasher.Read(id[:])
+	return id
+}
+
 // findDependencies recursively finds type dependencies
 func (e *FastTypedDataEncoder) findDependencies(typeName string, deps map[string]bool) {
 	if deps[typeName] {
@@ -432,8 +710,8 @@ func (e *FastTypedDataEncoder) findDependencies(typeName string, deps map[string
 	deps[typeName] = true
 	
 	for _, field := range fields {
-		// Remove array suffix if present
-		fieldType := strings.TrimSuffix(field.Type, "[]")
+		// Remove array suffixes if present
+		fieldType := baseTypeName(field.Type)
 		
 		// Check if it's a custom type
 		if _, ok := e.Types[fieldType]; ok {
@@ -449,45 +727,12 @@ func (e *FastTypedDataEncoder) validate() error {
 
 // buildDomainTypes builds the EIP712Domain type definition
 func (e *FastTypedDataEncoder) buildDomainTypes() []Type {
-	types := []Type{
-		{Name: "name", Type: "string"},
-		{Name: "version", Type: "string"},
-	}
-	
-	if e.Domain.ChainID != nil {
-		types = append(types, Type{Name: "chainId", Type: "uint256"})
-	}
-	
-	if e.Domain.VerifyingContract != (common.Address{}) {
-		types = append(types, Type{Name: "verifyingContract", Type: "address"})
-	}
-	
-	if e.Domain.Salt != [32]byte{} {
-		types = append(types, Type{Name: "salt", Type: "bytes32"})
-	}
-	
-	return types
+	return e.Domain.FieldSet().Types()
 }
 
 // domainToMap converts domain to map for encoding
-func (e *FastTypedDataEncoder) domainToMap() map[string]interface{} {
-	m := make(map[string]interface{})
-	m["name"] = e.Domain.Name
-	m["version"] = e.Domain.Version
-	
-	if e.Domain.ChainID != nil {
-		m["chainId"] = e.Domain.ChainID.String()
-	}
-	
-	if e.Domain.VerifyingContract != (common.Address{}) {
-		m["verifyingContract"] = e.Domain.VerifyingContract.Hex()
-	}
-	
-	if e.Domain.Salt != [32]byte{} {
-		m["salt"] = "0x" + hex.EncodeToString(e.Domain.Salt[:])
-	}
-	
-	return m
+func (e *FastTypedDataEncoder) domainToMap() (map[string]interface{}, error) {
+	return domainToMap(e.Domain, e.Types["EIP712Domain"])
 }
 
 // Helper conversion functions optimized for common cases
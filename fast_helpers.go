@@ -243,12 +243,7 @@ func NewFastSignerOptimized(privateKeyHex string, chainID int64) (*FastSignerOpt
 
 // NewSignerOptimized creates a signer with minimal allocations
 func NewSignerOptimized(privateKeyHex string, chainID int64) (*Signer, error) {
-	// Remove 0x prefix if present without allocation
-	if len(privateKeyHex) >= 2 && privateKeyHex[0] == '0' && privateKeyHex[1] == 'x' {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	privateKey, err := privateKeyFromHex(privateKeyHex)
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %w", err)
 	}
@@ -261,8 +256,8 @@ func NewSignerOptimized(privateKeyHex string, chainID int64) (*Signer, error) {
 	}
 	
 	return &Signer{
-		privateKey: privateKey,
-		address:    crypto.PubkeyToAddress(*publicKeyECDSA),
-		chainID:    big.NewInt(chainID),
+		key:     newSigningKey(privateKey),
+		address: crypto.PubkeyToAddress(*publicKeyECDSA),
+		chainID: big.NewInt(chainID),
 	}, nil
 }
\ No newline at end of file
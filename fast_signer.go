@@ -1,20 +1,19 @@
 package eip712
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // FastSigner provides high-performance EIP-712 signing using the optimized encoder
 type FastSigner struct {
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	chainID    *big.Int
+	key     *signingKey
+	address common.Address
+	chainID *big.Int
+	audit   AuditSink
 }
 
 // NewFastSigner creates a new fast EIP-712 signer
@@ -25,9 +24,9 @@ func NewFastSigner(privateKeyHex string, chainID int64) (*FastSigner, error) {
 	}
 	
 	return &FastSigner{
-		privateKey: signer.privateKey,
-		address:    signer.address,
-		chainID:    signer.chainID,
+		key:     signer.key,
+		address: signer.address,
+		chainID: signer.chainID,
 	}, nil
 }
 
@@ -43,21 +42,7 @@ func (s *FastSigner) SignTypedDataFast(domain Domain, types map[string][]Type, p
 	}
 	
 	// Sign the hash
-	signature, err := crypto.Sign(hash, s.privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
-	}
-	
-	// Transform V from 0/1 to 27/28 per Ethereum convention
-	signature[64] += 27
-	
-	return &Signature{
-		R:     hexutil.Encode(signature[:32]),
-		S:     hexutil.Encode(signature[32:64]),
-		V:     uint8(signature[64]),
-		Hash:  hexutil.Encode(hash),
-		Bytes: hexutil.Encode(signature),
-	}, nil
+	return s.signDigest(context.Background(), TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}, hash)
 }
 
 // Address returns the signer's address
@@ -70,6 +55,12 @@ func (s *FastSigner) ChainID() *big.Int {
 	return new(big.Int).Set(s.chainID)
 }
 
+// Close zeroes the signer's private key; signing afterwards returns
+// ErrSignerClosed
+func (s *FastSigner) Close() error {
+	return s.key.destroy()
+}
+
 // SignMessageFast signs a simple message using the optimized encoder
 func (s *FastSigner) SignMessageFast(appName string, message map[string]interface{}) (*Signature, error) {
 	domain := Domain{
@@ -160,26 +151,5 @@ func RecoverSignatureFast(
 		return common.Address{}, fmt.Errorf("failed to hash typed data: %w", err)
 	}
 	
-	// Decode signature
-	sigBytes, err := hexutil.Decode(sig.Bytes)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("invalid signature hex: %w", err)
-	}
-	
-	if len(sigBytes) != 65 {
-		return common.Address{}, fmt.Errorf("signature must be 65 bytes")
-	}
-	
-	// Transform V from 27/28 to 0/1 for recovery
-	if sigBytes[64] >= 27 {
-		sigBytes[64] -= 27
-	}
-	
-	// Recover public key
-	pubKey, err := crypto.SigToPub(hash, sigBytes)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to recover public key: %w", err)
-	}
-	
-	return crypto.PubkeyToAddress(*pubKey), nil
+	return recoverHash(hash, sig.Bytes)
 }
\ No newline at end of file
@@ -0,0 +1,240 @@
+package eip712
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 path of the first Ethereum account,
+// m/44'/60'/0'/0/0
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// bip32HardenedOffset marks a hardened child index
+const bip32HardenedOffset = 0x80000000
+
+// ErrInvalidMnemonic is returned when a mnemonic has an unknown word, a bad
+// length or a bad checksum
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// HDWallet derives Signers from a single BIP-39 seed along BIP-32 paths.
+// Derived signers hold their own copy of their key and stay usable after
+// the wallet is closed.
+type HDWallet struct {
+	mu          sync.RWMutex
+	masterKey   *big.Int
+	masterChain []byte
+	chainID     *big.Int
+}
+
+// GenerateMnemonic returns a new random BIP-39 mnemonic with the given
+// entropy, which must be a multiple of 32 between 128 and 256 bits
+func GenerateMnemonic(entropyBits int) (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewHDWallet creates a wallet from a BIP-39 mnemonic and optional passphrase
+//
+// Example:
+//
+//	wallet, err := NewHDWallet(mnemonic, "", 1)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	// m/44'/60'/0'/0/0 through m/44'/60'/0'/0/9
+//	signers, err := wallet.Signers(0, 10)
+func NewHDWallet(mnemonic, passphrase string, chainID int64) (*HDWallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	defer zeroBytes(seed)
+
+	return NewHDWalletFromSeed(seed, chainID)
+}
+
+// NewHDWalletFromSeed creates a wallet from a BIP-32 seed of 16 to 64 bytes
+func NewHDWalletFromSeed(seed []byte, chainID int64) (*HDWallet, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length: %d bytes", len(seed))
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	zeroBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		zeroBigInt(key)
+		zeroBytes(sum)
+		return nil, errors.New("seed produces an invalid master key")
+	}
+
+	return &HDWallet{
+		masterKey:   key,
+		masterChain: sum[32:],
+		chainID:     big.NewInt(chainID),
+	}, nil
+}
+
+// NewSignerFromMnemonic creates a signer for the key at derivationPath under
+// a BIP-39 mnemonic. An empty path selects DefaultDerivationPath; relative
+// paths are resolved under m/44'/60'/0'/0.
+func NewSignerFromMnemonic(mnemonic, passphrase, derivationPath string, chainID int64) (*Signer, error) {
+	wallet, err := NewHDWallet(mnemonic, passphrase, chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer wallet.Close()
+
+	if derivationPath == "" {
+		derivationPath = DefaultDerivationPath
+	}
+	return wallet.Derive(derivationPath)
+}
+
+// Derive returns a signer for the key at the given BIP-32 path. "m" selects
+// the master key.
+func (w *HDWallet) Derive(derivationPath string) (*Signer, error) {
+	if derivationPath == "m" {
+		return w.DerivePath(nil)
+	}
+	path, err := accounts.ParseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %w", err)
+	}
+	return w.DerivePath(path)
+}
+
+// DerivePath returns a signer for the key at a parsed BIP-32 path
+func (w *HDWallet) DerivePath(path accounts.DerivationPath) (*Signer, error) {
+	privateKey, err := w.deriveKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+	}
+
+	return &Signer{
+		key:     newSigningKey(privateKey),
+		address: crypto.PubkeyToAddress(privateKey.PublicKey),
+		chainID: new(big.Int).Set(w.chainID),
+	}, nil
+}
+
+// Signer returns the signer for account index under m/44'/60'/0'/0
+func (w *HDWallet) Signer(index uint32) (*Signer, error) {
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+	path[len(path)-1] = index
+	return w.DerivePath(path)
+}
+
+// Signers returns count consecutive signers under m/44'/60'/0'/0, starting
+// at account index start
+func (w *HDWallet) Signers(start, count uint32) ([]*Signer, error) {
+	signers := make([]*Signer, 0, count)
+	for i := uint32(0); i < count; i++ {
+		signer, err := w.Signer(start + i)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// Close zeroes the wallet's master key. Deriving afterwards returns
+// ErrSignerClosed.
+func (w *HDWallet) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.masterKey == nil {
+		return nil
+	}
+	zeroBigInt(w.masterKey)
+	zeroBytes(w.masterChain)
+	w.masterKey = nil
+	w.masterChain = nil
+	return nil
+}
+
+// deriveKey walks path from the master key using BIP-32 private child
+// derivation, wiping every intermediate key and chain code
+func (w *HDWallet) deriveKey(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.masterKey == nil {
+		return nil, ErrSignerClosed
+	}
+
+	n := crypto.S256().Params().N
+	key := new(big.Int).Set(w.masterKey)
+	defer zeroBigInt(key)
+	tweak := new(big.Int)
+	defer zeroBigInt(tweak)
+
+	chainCode := make([]byte, 32)
+	defer zeroBytes(chainCode)
+	copy(chainCode, w.masterChain)
+
+	data := make([]byte, 37)
+	defer zeroBytes(data)
+	sum := make([]byte, 0, sha512.Size)
+	defer zeroBytes(sum[:cap(sum)])
+	keyBytes := make([]byte, 32)
+	defer zeroBytes(keyBytes)
+
+	for _, index := range path {
+		math.ReadBits(key, keyBytes)
+		if index >= bip32HardenedOffset {
+			// 0x00 || ser256(k) || ser32(i)
+			data = data[:37]
+			data[0] = 0
+			copy(data[1:33], keyBytes)
+			binary.BigEndian.PutUint32(data[33:], index)
+		} else {
+			// serP(point(k)) || ser32(i)
+			privateKey, err := crypto.ToECDSA(keyBytes)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data[:0], crypto.CompressPubkey(&privateKey.PublicKey)...)
+			data = binary.BigEndian.AppendUint32(data, index)
+			zeroBigInt(privateKey.D)
+		}
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(sum[:0])
+
+		tweak.SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key.Add(key, tweak).Mod(key, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		copy(chainCode, sum[32:])
+	}
+
+	math.ReadBits(key, keyBytes)
+	return crypto.ToECDSA(keyBytes)
+}
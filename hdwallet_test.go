@@ -0,0 +1,113 @@
+package eip712
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestNewSignerFromMnemonic(t *testing.T) {
+	signer, err := NewSignerFromMnemonic(testMnemonic, "", "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress1), signer.Address())
+	assert.Equal(t, int64(1), signer.ChainID().Int64())
+
+	signer, err = NewSignerFromMnemonic(testMnemonic, "", "m/44'/60'/0'/0/1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress2), signer.Address())
+
+	// Relative paths resolve under m/44'/60'/0'/0
+	relative, err := NewSignerFromMnemonic(testMnemonic, "", "1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, signer.Address(), relative.Address())
+
+	withPassphrase, err := NewSignerFromMnemonic(testMnemonic, "secret", "", 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, common.HexToAddress(testAddress1), withPassphrase.Address())
+
+	_, err = NewSignerFromMnemonic("test test test", "", "", 1)
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+
+	_, err = NewSignerFromMnemonic(strings.Replace(testMnemonic, "junk", "test", 1), "", "", 1)
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+
+	_, err = NewSignerFromMnemonic(testMnemonic, "", "m/44'/x", 1)
+	assert.Error(t, err)
+}
+
+func TestHDWalletSigners(t *testing.T) {
+	wallet, err := NewHDWallet(testMnemonic, "", 1)
+	require.NoError(t, err)
+
+	signers, err := wallet.Signers(0, 3)
+	require.NoError(t, err)
+	require.Len(t, signers, 3)
+	assert.Equal(t, common.HexToAddress(testAddress1), signers[0].Address())
+	assert.Equal(t, common.HexToAddress(testAddress2), signers[1].Address())
+	assert.Equal(t, common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"), signers[2].Address())
+
+	signer, err := wallet.Signer(2)
+	require.NoError(t, err)
+	assert.Equal(t, signers[2].Address(), signer.Address())
+
+	// Derived signers sign like any other
+	sig, err := signer.SignTypedData(createTestDomain("Test", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"))
+	require.NoError(t, err)
+	valid, err := VerifySignature(sig, signer.Address(), createTestDomain("Test", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"))
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestHDWalletBIP32Vectors(t *testing.T) {
+	// BIP-32 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	wallet, err := NewHDWalletFromSeed(seed, 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			signer, err := wallet.Derive(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, hex.EncodeToString(crypto.FromECDSA(signer.key.privateKey)))
+		})
+	}
+
+	_, err = NewHDWalletFromSeed([]byte{1, 2, 3}, 1)
+	assert.Error(t, err)
+}
+
+func TestGenerateMnemonic(t *testing.T) {
+	mnemonic, err := GenerateMnemonic(128)
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 12)
+
+	mnemonic, err = GenerateMnemonic(256)
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+
+	_, err = NewHDWallet(mnemonic, "", 1)
+	assert.NoError(t, err)
+
+	_, err = GenerateMnemonic(100)
+	assert.Error(t, err)
+}
@@ -18,6 +18,13 @@ const (
 	testAddress2    = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
 )
 
+var (
+	testOwner   = common.HexToAddress(testAddress1)
+	testSpender = common.HexToAddress(testAddress2)
+	testUSDC    = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	testRouter  = common.HexToAddress("0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD")
+)
+
 // Helper functions for testing
 
 func assertSignatureComponents(t *testing.T, sig *Signature) {
@@ -140,6 +147,16 @@ func createPermitMessage(owner, spender string, value, nonce, deadline *big.Int)
 	}
 }
 
+// createTestPermit returns an EIP-2612 permit for USDC on mainnet
+func createTestPermit(owner, spender common.Address, value, nonce *big.Int) TypedData {
+	return TypedData{
+		Domain:      createTestDomainWithContract("USD Coin", "2", 1, testUSDC.Hex()),
+		Types:       createPermitTypes(),
+		PrimaryType: "Permit",
+		Message:     createPermitMessage(owner.Hex(), spender.Hex(), value, nonce, big.NewInt(1893456000)),
+	}
+}
+
 // Test table structures
 
 type signerTestCase struct {
@@ -0,0 +1,133 @@
+package eip712
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrSignerClosed is returned when a signer is used after Close
+var ErrSignerClosed = errors.New("signer is closed")
+
+// ErrMalformedPrivateKey is returned for a private key that is not 64 hex
+// characters, with or without 0x prefix
+var ErrMalformedPrivateKey = errors.New("malformed private key")
+
+// signingKey holds either an in-memory private key, which can be wiped while
+// other goroutines may still be signing with it, or an external KeySigner
+type signingKey struct {
+	mu         sync.RWMutex
+	privateKey *ecdsa.PrivateKey
+	external   KeySigner
+	closed     bool
+}
+
+func newSigningKey(privateKey *ecdsa.PrivateKey) *signingKey {
+	return &signingKey{privateKey: privateKey}
+}
+
+// sign signs a 32-byte digest with the key
+func (k *signingKey) sign(hash []byte) (*Signature, error) {
+	return k.signContext(context.Background(), hash)
+}
+
+// signContext signs a 32-byte digest with the key, passing ctx to external
+// signers that accept one
+func (k *signingKey) signContext(ctx context.Context, hash []byte) (*Signature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.closed {
+		return nil, ErrSignerClosed
+	}
+	if k.external != nil {
+		return signHashExternal(ctx, hash, k.external)
+	}
+	return signHash(hash, k.privateKey)
+}
+
+// use calls fn with the private key, which stays valid until fn returns.
+// fn must not retain the key.
+func (k *signingKey) use(fn func(*ecdsa.PrivateKey) error) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.closed {
+		return ErrSignerClosed
+	}
+	if k.privateKey == nil {
+		return ErrKeyNotExportable
+	}
+	return fn(k.privateKey)
+}
+
+// destroy zeroes the private scalar, or closes the external signer if it
+// is an io.Closer. It waits for in-flight signatures and is safe to call
+// more than once.
+func (k *signingKey) destroy() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return nil
+	}
+	k.closed = true
+
+	if k.privateKey != nil {
+		zeroBigInt(k.privateKey.D)
+		k.privateKey = nil
+	}
+	if closer, ok := k.external.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// privateKeyFromHex parses a hex private key, with or without 0x prefix,
+// wiping the decoded bytes afterwards
+func privateKeyFromHex(privateKeyHex string) (*ecdsa.PrivateKey, error) {
+	src := []byte(strings.TrimPrefix(privateKeyHex, "0x"))
+	defer zeroBytes(src)
+
+	if len(src) != 64 {
+		return nil, fmt.Errorf("%w: got %d hex characters, want 64", ErrMalformedPrivateKey, len(src))
+	}
+	raw := make([]byte, hex.DecodedLen(len(src)))
+	defer zeroBytes(raw)
+
+	if _, err := hex.Decode(raw, src); err != nil {
+		return nil, fmt.Errorf("%w: invalid hex character", ErrMalformedPrivateKey)
+	}
+	return crypto.ToECDSA(raw)
+}
+
+// zeroBytes overwrites b with zeros
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroBigInt overwrites the words backing n and sets it to zero
+func zeroBigInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
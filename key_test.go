@@ -0,0 +1,127 @@
+package eip712
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerClose(t *testing.T) {
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	d := signer.key.privateKey.D
+
+	_, err = signer.SignTypedData(domain, types, "Mail", message)
+	require.NoError(t, err)
+
+	require.NoError(t, signer.Close())
+	assert.Zero(t, d.Sign())
+	assert.Equal(t, common.HexToAddress(testAddress1), signer.Address())
+
+	_, err = signer.SignTypedData(domain, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+	_, err = signer.SignMessage("Test", map[string]interface{}{"a": "b"})
+	assert.ErrorIs(t, err, ErrSignerClosed)
+	_, err = signer.SignSeaportOrder(&SeaportOrder{}, SeaportV16Address)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+	_, err = signer.ExportKeystore("password", LightScryptParams)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+
+	// Closing twice is harmless
+	assert.NoError(t, signer.Close())
+}
+
+func TestFastAndOptimizedSignerClose(t *testing.T) {
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	fast, err := NewFastSignerOptimized(testPrivateKey1, 1)
+	require.NoError(t, err)
+	require.NoError(t, fast.Close())
+	_, err = fast.SignTypedDataFast(domain, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+	_, err = fast.SignPermitFast(common.HexToAddress(testAddress2), "Token", "1",
+		common.HexToAddress(testAddress1), big.NewInt(1), big.NewInt(0), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrSignerClosed)
+
+	optimized, err := NewOptimizedSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	require.NoError(t, optimized.Close())
+	_, err = optimized.SignTypedDataOptimized(domain, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+}
+
+func TestSignerCloseWhileSigning(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+			for j := 0; j < 20; j++ {
+				sig, err := signer.SignTypedData(domain, types, "Mail", message)
+				if err != nil {
+					assert.ErrorIs(t, err, ErrSignerClosed)
+					return
+				}
+				// A signature produced before Close must still be valid
+				valid, err := VerifySignature(sig, signer.Address(), domain, types, "Mail", message)
+				assert.NoError(t, err)
+				assert.True(t, valid)
+			}
+		}()
+	}
+	require.NoError(t, signer.Close())
+	wg.Wait()
+}
+
+func TestHDWalletClose(t *testing.T) {
+	wallet, err := NewHDWallet(testMnemonic, "", 1)
+	require.NoError(t, err)
+
+	signer, err := wallet.Signer(0)
+	require.NoError(t, err)
+
+	require.NoError(t, wallet.Close())
+	_, err = wallet.Signer(1)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+
+	// Derived signers own their key
+	_, err = signer.SignMessage("Test", map[string]interface{}{"a": "b"})
+	assert.NoError(t, err)
+}
+
+func TestPrivateKeyFromHex(t *testing.T) {
+	key, err := privateKeyFromHex(testPrivateKey1)
+	require.NoError(t, err)
+	withoutPrefix, err := privateKeyFromHex(testPrivateKey1[2:])
+	require.NoError(t, err)
+	assert.Equal(t, key.D, withoutPrefix.D)
+
+	_, err = privateKeyFromHex("0xzz")
+	assert.ErrorIs(t, err, ErrMalformedPrivateKey)
+	_, err = privateKeyFromHex("0x1234")
+	assert.ErrorIs(t, err, ErrMalformedPrivateKey)
+	_, err = privateKeyFromHex(testPrivateKey1 + "00")
+	assert.ErrorIs(t, err, ErrMalformedPrivateKey)
+	_, err = privateKeyFromHex("0x" + strings.Repeat("zz", 32))
+	assert.ErrorIs(t, err, ErrMalformedPrivateKey)
+	_, err = privateKeyFromHex("")
+	assert.ErrorIs(t, err, ErrMalformedPrivateKey)
+}
@@ -0,0 +1,159 @@
+package eip712
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrKeyNotExportable is returned when an operation needs the raw private
+// key of a signer backed by a KeySigner
+var ErrKeyNotExportable = errors.New("private key is not exportable")
+
+// secp256k1Order and secp256k1HalfOrder bound the S value of a low-S signature
+var (
+	secp256k1Order     = crypto.S256().Params().N
+	secp256k1HalfOrder = new(big.Int).Rsh(secp256k1Order, 1)
+)
+
+// KeySigner signs digests with a secp256k1 key held outside this package,
+// such as in an HSM or a cloud KMS. Implementations that hold resources
+// should also implement io.Closer; Signer.Close calls it.
+type KeySigner interface {
+	// Address returns the Ethereum address of the key
+	Address() common.Address
+	// SignHash signs a 32-byte digest and returns a 65-byte [R || S || V]
+	// signature with low S and V of 0/1 or 27/28
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// ContextKeySigner is a KeySigner whose signing can be cancelled, typically
+// because it makes a network call. Signer context methods pass their
+// context through to it.
+type ContextKeySigner interface {
+	KeySigner
+	SignHashContext(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// NewSignerWithKeySigner creates a signer that delegates signing to ks. All
+// Signer methods work as with a local key except ExportKeystore, which
+// returns ErrKeyNotExportable.
+//
+// Example:
+//
+//	hsm, err := NewPKCS11Signer(PKCS11Config{
+//	    Module:     "/usr/lib/softhsm/libsofthsm2.so",
+//	    TokenLabel: "signing",
+//	    PIN:        os.Getenv("HSM_PIN"),
+//	    KeyLabel:   "treasury",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	signer, err := NewSignerWithKeySigner(hsm, 1)
+//	defer signer.Close()
+func NewSignerWithKeySigner(ks KeySigner, chainID int64) (*Signer, error) {
+	if ks == nil {
+		return nil, errors.New("key signer is nil")
+	}
+
+	return &Signer{
+		key:     &signingKey{external: ks},
+		address: ks.Address(),
+		chainID: big.NewInt(chainID),
+	}, nil
+}
+
+// signHashExternal signs a digest with ks and checks that the signature
+// recovers to ks.Address()
+func signHashExternal(ctx context.Context, hash []byte, ks KeySigner) (*Signature, error) {
+	var signature []byte
+	var err error
+	if cks, ok := ks.(ContextKeySigner); ok {
+		signature, err = cks.SignHashContext(ctx, hash)
+	} else {
+		signature, err = ks.SignHash(hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("key signer returned %d-byte signature, want 65", len(signature))
+	}
+
+	signature = append([]byte(nil), signature...)
+	if signature[64] < 27 {
+		signature[64] += 27
+	}
+
+	recovered, err := recoverHash(hash, hexutil.Encode(signature))
+	if err != nil {
+		return nil, fmt.Errorf("key signer returned invalid signature: %w", err)
+	}
+	if recovered != ks.Address() {
+		return nil, fmt.Errorf("key signer signature recovers to %s, want %s", recovered.Hex(), ks.Address().Hex())
+	}
+
+	return newSignature(hash, signature), nil
+}
+
+// parseDERSignature parses an ASN.1 DER ECDSA-Sig-Value, the format returned
+// by most HSMs and cloud KMS services
+func parseDERSignature(der []byte) (r, s *big.Int, err error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DER signature: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, nil, errors.New("invalid DER signature: trailing data")
+	}
+	return sig.R, sig.S, nil
+}
+
+// parseRawSignature parses a 64-byte r || s ECDSA signature, the format
+// PKCS#11 CKM_ECDSA returns
+func parseRawSignature(raw []byte) (r, s *big.Int, err error) {
+	if len(raw) != 64 {
+		return nil, nil, fmt.Errorf("invalid raw signature length: %d", len(raw))
+	}
+	return new(big.Int).SetBytes(raw[:32]), new(big.Int).SetBytes(raw[32:]), nil
+}
+
+// recoverableSignature turns an ECDSA (r, s) over hash into a 65-byte
+// Ethereum [R || S || V] signature for address: S is normalized to the lower
+// half of the curve order (EIP-2) and V, 0 or 1, is found by trial recovery
+func recoverableSignature(hash []byte, r, s *big.Int, address common.Address) ([]byte, error) {
+	if r.Sign() <= 0 || r.Cmp(secp256k1Order) >= 0 || s.Sign() <= 0 || s.Cmp(secp256k1Order) >= 0 {
+		return nil, errors.New("signature values out of range")
+	}
+	if s.Cmp(secp256k1HalfOrder) > 0 {
+		s = new(big.Int).Sub(secp256k1Order, s)
+	}
+
+	signature := make([]byte, 65)
+	math.ReadBits(r, signature[:32])
+	math.ReadBits(s, signature[32:64])
+
+	for v := byte(0); v < 2; v++ {
+		signature[64] = v
+		publicKey, err := crypto.SigToPub(hash, signature)
+		if err != nil {
+			continue
+		}
+		if crypto.PubkeyToAddress(*publicKey) == address {
+			return signature, nil
+		}
+	}
+	return nil, fmt.Errorf("signature does not recover to %s", address.Hex())
+}
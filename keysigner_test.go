@@ -0,0 +1,153 @@
+package eip712
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// derKeySigner mimics an HSM: it signs with a plain ECDSA implementation,
+// producing DER signatures with random S, and converts them like the real
+// backends do
+type derKeySigner struct {
+	privateKey *ecdsa.PrivateKey
+	closed     bool
+}
+
+func (k *derKeySigner) Address() common.Address {
+	return crypto.PubkeyToAddress(k.privateKey.PublicKey)
+}
+
+func (k *derKeySigner) SignHash(hash []byte) ([]byte, error) {
+	der, err := ecdsa.SignASN1(rand.Reader, k.privateKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := parseDERSignature(der)
+	if err != nil {
+		return nil, err
+	}
+	return recoverableSignature(hash, r, s, k.Address())
+}
+
+func (k *derKeySigner) Close() error {
+	k.closed = true
+	return nil
+}
+
+// fixedKeySigner returns a canned signature
+type fixedKeySigner struct {
+	address   common.Address
+	signature []byte
+	err       error
+}
+
+func (k *fixedKeySigner) Address() common.Address { return k.address }
+
+func (k *fixedKeySigner) SignHash(hash []byte) ([]byte, error) { return k.signature, k.err }
+
+func TestNewSignerWithKeySigner(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+	ks := &derKeySigner{privateKey: privateKey}
+
+	signer, err := NewSignerWithKeySigner(ks, 1)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress1), signer.Address())
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	// Repeat so that both high-S normalization and both recovery IDs are hit
+	for i := 0; i < 16; i++ {
+		sig, err := signer.SignTypedData(domain, types, "Mail", message)
+		require.NoError(t, err)
+		assert.Contains(t, []uint8{27, 28}, sig.V)
+		assert.True(t, new(big.Int).SetBytes(common.FromHex(sig.S)).Cmp(secp256k1HalfOrder) <= 0)
+
+		valid, err := VerifySignature(sig, signer.Address(), domain, types, "Mail", message)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	_, err = signer.ExportKeystore("password", LightScryptParams)
+	assert.ErrorIs(t, err, ErrKeyNotExportable)
+
+	require.NoError(t, signer.Close())
+	assert.True(t, ks.closed)
+	_, err = signer.SignTypedData(domain, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrSignerClosed)
+
+	_, err = NewSignerWithKeySigner(nil, 1)
+	assert.Error(t, err)
+}
+
+func TestKeySignerSignatureChecks(t *testing.T) {
+	other, err := NewSigner(testPrivateKey2, 1)
+	require.NoError(t, err)
+	hash := crypto.Keccak256([]byte("digest"))
+	otherSig, err := other.key.sign(hash)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		ks   *fixedKeySigner
+	}{
+		{"backend error", &fixedKeySigner{address: common.HexToAddress(testAddress1), err: errors.New("hsm offline")}},
+		{"short signature", &fixedKeySigner{address: common.HexToAddress(testAddress1), signature: make([]byte, 64)}},
+		{"wrong key", &fixedKeySigner{address: common.HexToAddress(testAddress1), signature: common.FromHex(otherSig.Bytes)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signHashExternal(context.Background(), hash, tt.ks)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRecoverableSignature(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	hash := crypto.Keccak256([]byte("digest"))
+
+	expected, err := crypto.Sign(hash, privateKey)
+	require.NoError(t, err)
+	r := new(big.Int).SetBytes(expected[:32])
+	s := new(big.Int).SetBytes(expected[32:64])
+
+	// The high-S twin of a signature normalizes back to it
+	highS := new(big.Int).Sub(secp256k1Order, s)
+	for _, sv := range []*big.Int{s, highS} {
+		sig, err := recoverableSignature(hash, r, sv, address)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sig)
+	}
+
+	raw := append(math.PaddedBigBytes(r, 32), math.PaddedBigBytes(highS, 32)...)
+	rr, rs, err := parseRawSignature(raw)
+	require.NoError(t, err)
+	assert.Equal(t, r, rr)
+	assert.Equal(t, highS, rs)
+
+	_, err = recoverableSignature(hash, r, s, common.HexToAddress(testAddress2))
+	assert.Error(t, err)
+	_, err = recoverableSignature(hash, big.NewInt(0), s, address)
+	assert.Error(t, err)
+
+	_, _, err = parseDERSignature([]byte{0x30, 0x01})
+	assert.Error(t, err)
+	_, _, err = parseRawSignature(raw[:63])
+	assert.Error(t, err)
+}
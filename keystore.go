@@ -0,0 +1,89 @@
+package eip712
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/google/uuid"
+)
+
+// ScryptParams sets the cost of the scrypt KDF protecting a keystore file
+type ScryptParams struct {
+	N int
+	P int
+}
+
+var (
+	// StandardScryptParams uses about 256MB of memory and a second of CPU
+	// time, the go-ethereum default for keystore files
+	StandardScryptParams = ScryptParams{N: keystore.StandardScryptN, P: keystore.StandardScryptP}
+
+	// LightScryptParams uses about 4MB of memory and 100ms of CPU time, for
+	// constrained environments and tests
+	LightScryptParams = ScryptParams{N: keystore.LightScryptN, P: keystore.LightScryptP}
+)
+
+// orDefault returns StandardScryptParams for zero-valued params
+func (p ScryptParams) orDefault() ScryptParams {
+	if p.N == 0 && p.P == 0 {
+		return StandardScryptParams
+	}
+	return p
+}
+
+// ExportKeystore encrypts the signer's private key as a Web3 Secret Storage
+// (v3) keystore file, readable by NewSignerFromKeystore and geth. Zero params
+// select StandardScryptParams.
+//
+// Example:
+//
+//	keystoreJSON, err := signer.ExportKeystore(password, StandardScryptParams)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("key.json", keystoreJSON, 0600)
+func (s *Signer) ExportKeystore(password string, params ScryptParams) ([]byte, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key id: %w", err)
+	}
+
+	params = params.orDefault()
+	var keystoreJSON []byte
+	err = s.key.use(func(privateKey *ecdsa.PrivateKey) error {
+		keystoreJSON, err = keystore.EncryptKey(&keystore.Key{
+			Id:         id,
+			Address:    s.address,
+			PrivateKey: privateKey,
+		}, password, params.N, params.P)
+		return err
+	})
+	if errors.Is(err, ErrSignerClosed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt keystore: %w", err)
+	}
+	return keystoreJSON, nil
+}
+
+// ReencryptKeystore decrypts a keystore file with oldPassword and encrypts
+// it again with newPassword and params, keeping its key id. Use it to rotate
+// passwords or raise the KDF cost; zero params select StandardScryptParams.
+func ReencryptKeystore(keystoreJSON []byte, oldPassword, newPassword string, params ScryptParams) ([]byte, error) {
+	key, err := keystore.DecryptKey(keystoreJSON, oldPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+	}
+
+	defer zeroBigInt(key.PrivateKey.D)
+
+	params = params.orDefault()
+	reencrypted, err := keystore.EncryptKey(key, newPassword, params.N, params.P)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt keystore: %w", err)
+	}
+	return reencrypted, nil
+}
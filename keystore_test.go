@@ -0,0 +1,69 @@
+package eip712
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportKeystore(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	keystoreJSON, err := signer.ExportKeystore("password", LightScryptParams)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Address string `json:"address"`
+		Version int    `json:"version"`
+		Crypto  struct {
+			KDFParams struct {
+				N int `json:"n"`
+				P int `json:"p"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	require.NoError(t, json.Unmarshal(keystoreJSON, &parsed))
+	assert.Equal(t, 3, parsed.Version)
+	assert.Equal(t, LightScryptParams.N, parsed.Crypto.KDFParams.N)
+	assert.Equal(t, LightScryptParams.P, parsed.Crypto.KDFParams.P)
+	assert.Equal(t, common.HexToAddress(testAddress1), common.HexToAddress(parsed.Address))
+
+	restored, err := NewSignerFromKeystore(keystoreJSON, "password", 5)
+	require.NoError(t, err)
+	assert.Equal(t, signer.Address(), restored.Address())
+	assert.Equal(t, signer.key.privateKey.D, restored.key.privateKey.D)
+
+	_, err = NewSignerFromKeystore(keystoreJSON, "wrong", 5)
+	assert.Error(t, err)
+}
+
+func TestReencryptKeystore(t *testing.T) {
+	keystoreJSON, err := os.ReadFile("testdata/test_keystore.json")
+	require.NoError(t, err)
+
+	reencrypted, err := ReencryptKeystore(keystoreJSON, "testpassword", "rotated", LightScryptParams)
+	require.NoError(t, err)
+
+	var before, after struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(keystoreJSON, &before))
+	require.NoError(t, json.Unmarshal(reencrypted, &after))
+	assert.Equal(t, before.ID, after.ID)
+
+	signer, err := NewSignerFromKeystore(reencrypted, "rotated", 1)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress1), signer.Address())
+
+	_, err = NewSignerFromKeystore(reencrypted, "testpassword", 1)
+	assert.Error(t, err)
+
+	_, err = ReencryptKeystore(keystoreJSON, "wrong", "rotated", LightScryptParams)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt keystore")
+}
@@ -0,0 +1,146 @@
+package eip712
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// oidECPublicKey and oidSecp256k1 identify a secp256k1 SubjectPublicKeyInfo
+var (
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// KMSClient is the part of a cloud key management service a KMSSigner
+// needs. Adapters for AWS KMS, GCP Cloud KMS or Azure Key Vault are a few
+// lines each, and tests can substitute a local fake.
+type KMSClient interface {
+	// PublicKey returns the public key of keyID as a DER or PEM
+	// SubjectPublicKeyInfo, or as a raw SEC 1 point
+	PublicKey(ctx context.Context, keyID string) ([]byte, error)
+	// Sign signs a 32-byte digest, without hashing it again, and returns a
+	// DER ECDSA signature
+	Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// KMSSigner is a KeySigner backed by a secp256k1 key in a cloud KMS. It
+// converts the DER signatures KMS services return, which have no recovery
+// id and may have high S, into Ethereum signatures.
+type KMSSigner struct {
+	client  KMSClient
+	keyID   string
+	address common.Address
+	// Timeout bounds each Sign call; zero means no timeout
+	Timeout time.Duration
+}
+
+// NewKMSSigner fetches the public key of keyID and returns a signer for it
+//
+// Example:
+//
+//	kms, err := NewKMSSigner(ctx, awsKMSClient{svc}, "alias/treasury")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	signer, err := NewSignerWithKeySigner(kms, 1)
+//	sig, err := signer.SignPermit(token, "USD Coin", "2", spender, value, nonce, deadline)
+func NewKMSSigner(ctx context.Context, client KMSClient, keyID string) (*KMSSigner, error) {
+	der, err := client.PublicKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public key of %s: %w", keyID, err)
+	}
+	publicKey, err := parseSecp256k1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of %s: %w", keyID, err)
+	}
+
+	return &KMSSigner{
+		client:  client,
+		keyID:   keyID,
+		address: crypto.PubkeyToAddress(*publicKey),
+	}, nil
+}
+
+// Address returns the Ethereum address of the KMS key
+func (s *KMSSigner) Address() common.Address {
+	return s.address
+}
+
+// KeyID returns the KMS key identifier
+func (s *KMSSigner) KeyID() string {
+	return s.keyID
+}
+
+// SignHash signs a 32-byte digest in the KMS and returns a low-S
+// [R || S || V] signature
+func (s *KMSSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext is SignHash with a context for the KMS call. Timeout, if
+// set, applies on top of any ctx deadline.
+func (s *KMSSigner) SignHashContext(ctx context.Context, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	der, err := s.client.Sign(ctx, s.keyID, hash)
+	if err != nil {
+		return nil, fmt.Errorf("KMS sign failed: %w", err)
+	}
+	r, sv, err := parseDERSignature(der)
+	if err != nil {
+		return nil, err
+	}
+	return recoverableSignature(hash, r, sv, s.address)
+}
+
+// parseSecp256k1PublicKey parses a secp256k1 public key given as a PEM or DER
+// SubjectPublicKeyInfo or as a compressed or uncompressed SEC 1 point.
+// x509.ParsePKIXPublicKey does not support the curve.
+func parseSecp256k1PublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	switch {
+	case len(data) == 65 && data[0] == 0x04:
+		return crypto.UnmarshalPubkey(data)
+	case len(data) == 33 && (data[0] == 0x02 || data[0] == 0x03):
+		return crypto.DecompressPubkey(data)
+	}
+
+	var spki struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}
+	rest, err := asn1.Unmarshal(data, &spki)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SubjectPublicKeyInfo: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("invalid SubjectPublicKeyInfo: trailing data")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidECPublicKey) || !spki.Algorithm.Parameters.Equal(oidSecp256k1) {
+		return nil, errors.New("not a secp256k1 public key")
+	}
+	return crypto.UnmarshalPubkey(spki.PublicKey.RightAlign())
+}
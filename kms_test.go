@@ -0,0 +1,173 @@
+package eip712
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMSClient holds keys locally and signs like a cloud KMS: DER output,
+// no recovery id, and S in either half of the curve order
+type fakeKMSClient struct {
+	keys  map[string]*ecdsa.PrivateKey
+	pem   bool
+	delay time.Duration
+}
+
+func (c *fakeKMSClient) PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	der, err := asn1.Marshal(struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}{
+		Algorithm: struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}{oidECPublicKey, oidSecp256k1},
+		PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&key.PublicKey), BitLength: 520},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.pem {
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	}
+	return der, nil
+}
+
+func (c *fakeKMSClient) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return ecdsa.SignASN1(rand.Reader, key, digest)
+}
+
+func newFakeKMSClient(t *testing.T) *fakeKMSClient {
+	key, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+	return &fakeKMSClient{keys: map[string]*ecdsa.PrivateKey{"alias/test": key}}
+}
+
+func TestKMSSigner(t *testing.T) {
+	for _, usePEM := range []bool{false, true} {
+		client := newFakeKMSClient(t)
+		client.pem = usePEM
+
+		kms, err := NewKMSSigner(context.Background(), client, "alias/test")
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToAddress(testAddress1), kms.Address())
+		assert.Equal(t, "alias/test", kms.KeyID())
+	}
+
+	kms, err := NewKMSSigner(context.Background(), newFakeKMSClient(t), "alias/test")
+	require.NoError(t, err)
+	signer, err := NewSignerWithKeySigner(kms, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	for i := 0; i < 16; i++ {
+		sig, err := signer.SignTypedData(domain, types, "Mail", message)
+		require.NoError(t, err)
+		assert.True(t, new(big.Int).SetBytes(common.FromHex(sig.S)).Cmp(secp256k1HalfOrder) <= 0)
+
+		valid, err := VerifySignature(sig, signer.Address(), domain, types, "Mail", message)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	// A KMS permit signs the same digest a local key would
+	local, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	token := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	spender := common.HexToAddress(testAddress2)
+	remotePermit, err := signer.SignPermit(token, "Token", "1", spender, big.NewInt(100), big.NewInt(0), big.NewInt(1e10))
+	require.NoError(t, err)
+	localPermit, err := local.SignPermit(token, "Token", "1", spender, big.NewInt(100), big.NewInt(0), big.NewInt(1e10))
+	require.NoError(t, err)
+	assert.Equal(t, localPermit.Hash, remotePermit.Hash)
+	recovered, err := recoverHash(common.FromHex(remotePermit.Hash), remotePermit.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, local.Address(), recovered)
+}
+
+func TestKMSSignerErrors(t *testing.T) {
+	client := newFakeKMSClient(t)
+
+	_, err := NewKMSSigner(context.Background(), client, "alias/missing")
+	assert.Error(t, err)
+
+	kms, err := NewKMSSigner(context.Background(), client, "alias/test")
+	require.NoError(t, err)
+
+	_, err = kms.SignHash([]byte{1, 2, 3})
+	assert.Error(t, err)
+
+	client.delay = time.Second
+	kms.Timeout = 10 * time.Millisecond
+	_, err = kms.SignHash(crypto.Keccak256([]byte("digest")))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestParseSecp256k1PublicKey(t *testing.T) {
+	key, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+
+	for _, encoded := range [][]byte{
+		crypto.FromECDSAPub(&key.PublicKey),
+		crypto.CompressPubkey(&key.PublicKey),
+	} {
+		parsed, err := parseSecp256k1PublicKey(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToAddress(testAddress1), crypto.PubkeyToAddress(*parsed))
+	}
+
+	// A P-256 SubjectPublicKeyInfo is rejected
+	p256, err := asn1.Marshal(struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}{
+		Algorithm: struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}{oidECPublicKey, asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}},
+		PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&key.PublicKey), BitLength: 520},
+	})
+	require.NoError(t, err)
+	_, err = parseSecp256k1PublicKey(p256)
+	assert.Error(t, err)
+
+	_, err = parseSecp256k1PublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,244 @@
+package eip712
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleRootType is the primary type signed over a merkle batch. Contracts
+// verify the signature over
+//
+//	MerkleRoot(bytes32 root,uint256 count)
+//
+// and then an item's inclusion with OpenZeppelin's MerkleProof.verify,
+// using the item's EIP-712 digest as the leaf.
+const MerkleRootType = "MerkleRoot"
+
+// merkleRootTypes are the types of the signed merkle root struct
+var merkleRootTypes = map[string][]Type{
+	MerkleRootType: {
+		{Name: "root", Type: "bytes32"},
+		{Name: "count", Type: "uint256"},
+	},
+}
+
+// ErrInvalidMerkleProof is returned when an item is not included under the
+// signed root
+var ErrInvalidMerkleProof = errors.New("invalid merkle proof")
+
+// MerkleBatch is a set of typed-data items covered by one signature over
+// the root of a merkle tree of their EIP-712 digests. Pairs of nodes are
+// hashed in sorted order, so proofs need no left/right flags; an unpaired
+// node moves up a level unchanged.
+type MerkleBatch struct {
+	Root      common.Hash
+	Count     int
+	Signature *Signature
+	// Leaves are the item digests in item order
+	Leaves []common.Hash
+	levels [][]common.Hash
+}
+
+// MerkleProof proves that one item belongs to a signed MerkleBatch
+type MerkleProof struct {
+	Index     int
+	Leaf      common.Hash
+	Siblings  []common.Hash
+	Root      common.Hash
+	Count     int
+	Signature *Signature
+}
+
+// SignMerkleBatch hashes every item with the fast encoder, builds a merkle
+// tree of the digests and signs its root once, as a MerkleRoot struct in
+// domain. With a KeySigner this costs one remote signature for the whole
+// batch. The signer's policies are applied to every item twice: in its own
+// domain, and in domain, where the root signature authorizes it. So a
+// verifying contract or chain allow-list also covers the root's domain,
+// while primary type and message policies never see the MerkleRoot struct.
+//
+// Example:
+//
+//	batch, err := signer.SignMerkleBatch(ctx, settlementDomain, orders)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for i := range orders {
+//	    proof, _ := batch.Proof(i)
+//	    submit(orders[i], proof)
+//	}
+func (s *Signer) SignMerkleBatch(ctx context.Context, domain Domain, items []TypedData) (*MerkleBatch, error) {
+	if len(items) == 0 {
+		return nil, errors.New("merkle batch is empty")
+	}
+
+	for i, item := range items {
+		if err := s.checkPolicies(item); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		if err := s.checkPolicies(inDomain(item, domain)); err != nil {
+			return nil, fmt.Errorf("item %d in merkle root domain: %w", i, err)
+		}
+	}
+
+	leaves, err := hashItems(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	levels := buildMerkleLevels(leaves)
+	root := levels[len(levels)-1][0]
+
+	// The items have passed the policies in the root's domain, so the
+	// synthetic root document is not checked again
+	rootData := TypedData{Domain: domain, Types: merkleRootTypes, PrimaryType: MerkleRootType, Message: merkleRootMessage(root, len(items))}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	hash, err := typedDataHash(rootData.Domain, rootData.Types, rootData.PrimaryType, rootData.Message, s.buildDomainTypes(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash merkle root: %w", err)
+	}
+	sig, err := s.signDigest(ctx, rootData, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign merkle root: %w", err)
+	}
+
+	return &MerkleBatch{
+		Root:      root,
+		Count:     len(items),
+		Signature: sig,
+		Leaves:    leaves,
+		levels:    levels,
+	}, nil
+}
+
+// inDomain returns item as defined in domain, dropping any explicit
+// EIP712Domain type of its own
+func inDomain(item TypedData, domain Domain) TypedData {
+	if _, ok := item.Types["EIP712Domain"]; ok {
+		types := make(map[string][]Type, len(item.Types)-1)
+		for name, fields := range item.Types {
+			if name != "EIP712Domain" {
+				types[name] = fields
+			}
+		}
+		item.Types = types
+	}
+	item.Domain = domain
+	return item
+}
+
+// Proof returns the inclusion proof of item i
+func (b *MerkleBatch) Proof(i int) (*MerkleProof, error) {
+	if i < 0 || i >= len(b.Leaves) {
+		return nil, fmt.Errorf("item %d out of range [0, %d)", i, len(b.Leaves))
+	}
+
+	var siblings []common.Hash
+	index := i
+	for _, level := range b.levels[:len(b.levels)-1] {
+		if sibling := index ^ 1; sibling < len(level) {
+			siblings = append(siblings, level[sibling])
+		}
+		index /= 2
+	}
+
+	return &MerkleProof{
+		Index:     i,
+		Leaf:      b.Leaves[i],
+		Siblings:  siblings,
+		Root:      b.Root,
+		Count:     b.Count,
+		Signature: b.Signature,
+	}, nil
+}
+
+// VerifyMerkleProof checks that item is included under proof's root and
+// that expectedSigner signed that root in domain. It returns false with
+// ErrInvalidMerkleProof when the item is not in the tree, and false with a
+// nil error when the root was signed by another address.
+func VerifyMerkleProof(proof *MerkleProof, expectedSigner common.Address, domain Domain, item TypedData) (bool, error) {
+	if proof == nil || proof.Signature == nil {
+		return false, errors.New("merkle proof has no signature")
+	}
+
+	leaf, err := NewFastTypedDataEncoder(item.Domain, item.Types, item.PrimaryType, item.Message).Hash()
+	if err != nil {
+		return false, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	node := common.BytesToHash(leaf)
+	for _, sibling := range proof.Siblings {
+		node = hashMerklePair(node, sibling)
+	}
+	if node != proof.Root {
+		return false, ErrInvalidMerkleProof
+	}
+
+	return VerifySignatureFast(proof.Signature, expectedSigner, domain, merkleRootTypes, MerkleRootType, merkleRootMessage(proof.Root, proof.Count))
+}
+
+// hashItems returns the EIP-712 digests of items, computed in parallel
+func hashItems(ctx context.Context, items []TypedData) ([]common.Hash, error) {
+	leaves := make([]common.Hash, len(items))
+	errs := make([]error, len(items))
+	schemas := prepareSchemas(len(items), func(i int) TypedData { return items[i] })
+
+	runBatch(len(items), 0, func(encoder *FastTypedDataEncoder, i int) {
+		hash, err := batchHash(ctx, encoder, items[i], schemas)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		leaves[i] = common.BytesToHash(hash)
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return leaves, nil
+}
+
+// buildMerkleLevels returns every level of the tree over leaves, from the
+// leaves up to the single root
+func buildMerkleLevels(leaves []common.Hash) [][]common.Hash {
+	levels := [][]common.Hash{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashMerklePair(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// hashMerklePair hashes two nodes in ascending order, as OpenZeppelin's
+// MerkleProof does
+func hashMerklePair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// merkleRootMessage builds the MerkleRoot struct signed over a batch
+func merkleRootMessage(root common.Hash, count int) Message {
+	return Message{
+		"root":  root.Hex(),
+		"count": big.NewInt(int64(count)).String(),
+	}
+}
@@ -0,0 +1,193 @@
+package eip712
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingKeySigner counts the digests it signs
+type countingKeySigner struct {
+	derKeySigner
+	calls int
+}
+
+func (k *countingKeySigner) SignHash(hash []byte) ([]byte, error) {
+	k.calls++
+	return k.derKeySigner.SignHash(hash)
+}
+
+func TestSignMerkleBatch(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	domain := createTestDomainWithContract("Settlement", "1", 1, testAddress2)
+
+	for _, n := range []int{1, 2, 3, 7, 16, 33} {
+		items := createPermitBatch(signer.Address(), n)
+		batch, err := signer.SignMerkleBatch(context.Background(), domain, items)
+		require.NoError(t, err, "n=%d", n)
+		assert.Equal(t, n, batch.Count)
+		require.Len(t, batch.Leaves, n)
+
+		for i, item := range items {
+			want, err := NewFastTypedDataEncoder(item.Domain, item.Types, item.PrimaryType, item.Message).Hash()
+			require.NoError(t, err)
+			assert.Equal(t, common.BytesToHash(want), batch.Leaves[i])
+
+			proof, err := batch.Proof(i)
+			require.NoError(t, err)
+			valid, err := VerifyMerkleProof(proof, signer.Address(), domain, item)
+			require.NoError(t, err, "n=%d item %d", n, i)
+			assert.True(t, valid, "n=%d item %d", n, i)
+		}
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	domain := createTestDomain("Settlement", "1", 1)
+
+	items := createPermitBatch(signer.Address(), 3)
+	batch, err := signer.SignMerkleBatch(context.Background(), domain, items)
+	require.NoError(t, err)
+
+	// Sorted-pair hashing, with the third leaf promoted unchanged
+	pair := func(a, b common.Hash) common.Hash {
+		if a.Big().Cmp(b.Big()) > 0 {
+			a, b = b, a
+		}
+		return crypto.Keccak256Hash(a[:], b[:])
+	}
+	leaves := batch.Leaves
+	assert.Equal(t, pair(pair(leaves[0], leaves[1]), leaves[2]), batch.Root)
+
+	// The signature is over MerkleRoot(bytes32 root,uint256 count)
+	valid, err := VerifySignature(batch.Signature, signer.Address(), domain, merkleRootTypes, MerkleRootType,
+		Message{"root": batch.Root.Hex(), "count": "3"})
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifyMerkleProofRejects(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	domain := createTestDomain("Settlement", "1", 1)
+
+	items := createPermitBatch(signer.Address(), 5)
+	batch, err := signer.SignMerkleBatch(context.Background(), domain, items)
+	require.NoError(t, err)
+	proof, err := batch.Proof(2)
+	require.NoError(t, err)
+
+	t.Run("item not in batch", func(t *testing.T) {
+		other := createPermitBatch(signer.Address(), 6)[5]
+		valid, err := VerifyMerkleProof(proof, signer.Address(), domain, other)
+		assert.ErrorIs(t, err, ErrInvalidMerkleProof)
+		assert.False(t, valid)
+	})
+
+	t.Run("wrong signer", func(t *testing.T) {
+		valid, err := VerifyMerkleProof(proof, common.HexToAddress(testAddress2), domain, items[2])
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("wrong domain", func(t *testing.T) {
+		valid, err := VerifyMerkleProof(proof, signer.Address(), createTestDomain("Settlement", "2", 1), items[2])
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("forged count", func(t *testing.T) {
+		forged := *proof
+		forged.Count = 6
+		valid, err := VerifyMerkleProof(&forged, signer.Address(), domain, items[2])
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		_, err := VerifyMerkleProof(&MerkleProof{}, signer.Address(), domain, items[2])
+		assert.Error(t, err)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := batch.Proof(5)
+		assert.Error(t, err)
+		_, err = batch.Proof(-1)
+		assert.Error(t, err)
+	})
+}
+
+func TestSignMerkleBatchErrors(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	domain := createTestDomain("Settlement", "1", 1)
+
+	_, err = signer.SignMerkleBatch(context.Background(), domain, nil)
+	assert.Error(t, err)
+
+	items := createPermitBatch(signer.Address(), 4)
+	items[2].Message = Message{"owner": "not an address"}
+	_, err = signer.SignMerkleBatch(context.Background(), domain, items)
+	assert.ErrorContains(t, err, "item 2")
+}
+
+func TestSignMerkleBatchPolicies(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	settlement := common.HexToAddress(testAddress2)
+	signer.SetPolicy(AllPolicies(AllowVerifyingContracts(testUSDC, settlement), AllowChainIDs(1), DenyPrimaryTypesExcept("Permit")))
+	domain := createTestDomainWithContract("Settlement", "1", 1, testAddress2)
+
+	// The primary type allow-list applies to the items, not the synthetic
+	// root
+	items := createPermitBatch(signer.Address(), 3)
+	_, err = signer.SignMerkleBatch(context.Background(), domain, items)
+	require.NoError(t, err)
+
+	items[1].Domain.VerifyingContract = testRouter
+	_, err = signer.SignMerkleBatch(context.Background(), domain, items)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+	assert.ErrorContains(t, err, "item 1")
+
+	// The domain allow-lists also apply to the root's domain
+	items = createPermitBatch(signer.Address(), 3)
+	_, err = signer.SignMerkleBatch(context.Background(), createTestDomainWithContract("Settlement", "1", 1, testRouter.Hex()), items)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+	assert.ErrorContains(t, err, "merkle root domain")
+	_, err = signer.SignMerkleBatch(context.Background(), createTestDomainWithContract("Settlement", "1", 10, testAddress2), items)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+	_, err = signer.SignMerkleBatch(context.Background(), createTestDomain("Settlement", "1", 1), items)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	signer.SetPolicy(nil)
+	signer.SetEnforceChain(true)
+	_, err = signer.SignMerkleBatch(context.Background(), createTestDomainWithContract("Settlement", "1", 137, testAddress2), createPermitBatch(signer.Address(), 3))
+	assert.ErrorIs(t, err, ErrChainMismatch)
+}
+
+func TestSignMerkleBatchSignsOnce(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey1[2:])
+	require.NoError(t, err)
+	ks := &countingKeySigner{derKeySigner: derKeySigner{privateKey: privateKey}}
+	signer, err := NewSignerWithKeySigner(ks, 1)
+	require.NoError(t, err)
+	domain := createTestDomain("Settlement", "1", 1)
+
+	items := createPermitBatch(signer.Address(), 100)
+	batch, err := signer.SignMerkleBatch(context.Background(), domain, items)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ks.calls)
+
+	proof, err := batch.Proof(42)
+	require.NoError(t, err)
+	valid, err := VerifyMerkleProof(proof, signer.Address(), domain, items[42])
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
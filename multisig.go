@@ -0,0 +1,207 @@
+package eip712
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// ErrThresholdNotMet is returned when fewer valid signatures than the
+	// threshold have been collected
+	ErrThresholdNotMet = errors.New("signature threshold not met")
+	// ErrDuplicateSigner is returned when an owner signs more than once
+	ErrDuplicateSigner = errors.New("duplicate signer")
+	// ErrUnknownSigner is returned when a signature comes from an address
+	// that is not an owner
+	ErrUnknownSigner = errors.New("signer is not an owner")
+)
+
+// MultiSig collects signatures from several owners over one typed-data
+// digest, for contracts that verify M-of-N ECDSA signatures. Each signature
+// is verified as it is added. MultiSig is safe for concurrent use.
+type MultiSig struct {
+	domain      Domain
+	types       map[string][]Type
+	primaryType string
+	message     Message
+	hash        []byte
+	threshold   int
+	owners      map[common.Address]bool
+
+	mu         sync.Mutex
+	signatures map[common.Address]*Signature
+}
+
+// NewMultiSig creates a collector for threshold-of-owners signatures over
+// the typed data. A nil owners list accepts any signer.
+//
+// Example:
+//
+//	ms, err := NewMultiSig(domain, types, "Transaction", message, 2, owners)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	// Signatures may be added as they arrive from each owner
+//	if _, err := ms.Add(aliceSig); err != nil {
+//	    log.Fatal(err)
+//	}
+//	ms.Sign(bobSigner)
+//
+//	signatures, err := ms.Combined() // sorted by owner address
+func NewMultiSig(domain Domain, types map[string][]Type, primaryType string, message Message, threshold int, owners []common.Address) (*MultiSig, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("threshold must be at least 1, got %d", threshold)
+	}
+
+	var ownerSet map[common.Address]bool
+	if owners != nil {
+		ownerSet = make(map[common.Address]bool, len(owners))
+		for _, owner := range owners {
+			if ownerSet[owner] {
+				return nil, fmt.Errorf("duplicate owner %s", owner.Hex())
+			}
+			ownerSet[owner] = true
+		}
+		if threshold > len(ownerSet) {
+			return nil, fmt.Errorf("threshold %d exceeds %d owners", threshold, len(ownerSet))
+		}
+	}
+
+	if err := validateNoCycles(types); err != nil {
+		return nil, err
+	}
+	hash, err := typedDataHash(domain, types, primaryType, message, buildDomainTypesStatic(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	return &MultiSig{
+		domain:      domain,
+		types:       types,
+		primaryType: primaryType,
+		message:     message,
+		hash:        hash,
+		threshold:   threshold,
+		owners:      ownerSet,
+		signatures:  make(map[common.Address]*Signature),
+	}, nil
+}
+
+// Hash returns the digest every owner signs
+func (m *MultiSig) Hash() []byte {
+	return append([]byte(nil), m.hash...)
+}
+
+// Threshold returns the number of signatures required
+func (m *MultiSig) Threshold() int {
+	return m.threshold
+}
+
+// Add verifies a signature over the digest and records it under the
+// recovered address, which it returns
+func (m *MultiSig) Add(sig *Signature) (common.Address, error) {
+	if sig.Hash != "" && sig.Hash != hexutil.Encode(m.hash) {
+		return common.Address{}, fmt.Errorf("signature is over %s, want %s", sig.Hash, hexutil.Encode(m.hash))
+	}
+
+	signer, err := recoverHash(m.hash, sig.Bytes)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if m.owners != nil && !m.owners[signer] {
+		return signer, fmt.Errorf("%w: %s", ErrUnknownSigner, signer.Hex())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.signatures[signer]; ok {
+		return signer, fmt.Errorf("%w: %s", ErrDuplicateSigner, signer.Hex())
+	}
+	m.signatures[signer] = sig
+	return signer, nil
+}
+
+// Sign has each signer sign the typed data and adds the signatures. It
+// stops at the first failure.
+func (m *MultiSig) Sign(signers ...TypedDataSigner) error {
+	for _, signer := range signers {
+		sig, err := signer.SignTypedData(m.domain, m.types, m.primaryType, m.message)
+		if err != nil {
+			return fmt.Errorf("signing as %s failed: %w", signer.Address().Hex(), err)
+		}
+		if _, err := m.Add(sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Signers returns the addresses that have signed, in ascending order
+func (m *MultiSig) Signers() []common.Address {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	signers := make([]common.Address, 0, len(m.signatures))
+	for signer := range m.signatures {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	return signers
+}
+
+// Count returns the number of signatures collected
+func (m *MultiSig) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.signatures)
+}
+
+// Ready reports whether the threshold has been reached
+func (m *MultiSig) Ready() bool {
+	return m.Count() >= m.threshold
+}
+
+// Signatures returns the collected signatures ordered by ascending signer
+// address
+func (m *MultiSig) Signatures() []*Signature {
+	signers := m.Signers()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	signatures := make([]*Signature, len(signers))
+	for i, signer := range signers {
+		signatures[i] = m.signatures[signer]
+	}
+	return signatures
+}
+
+// Combined returns the concatenated 65-byte signatures ordered by ascending
+// signer address, the layout Safe's checkSignatures and similar contracts
+// expect. It fails with ErrThresholdNotMet until enough owners have signed.
+func (m *MultiSig) Combined() ([]byte, error) {
+	signatures := m.Signatures()
+	if len(signatures) < m.threshold {
+		return nil, fmt.Errorf("%w: have %d of %d", ErrThresholdNotMet, len(signatures), m.threshold)
+	}
+
+	combined := make([]byte, 0, 65*len(signatures))
+	for _, sig := range signatures {
+		raw, err := hexutil.Decode(sig.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		combined = append(combined, raw...)
+	}
+	return combined, nil
+}
@@ -0,0 +1,115 @@
+package eip712
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultiSigTestSigners(t *testing.T, count uint32) []*Signer {
+	wallet, err := NewHDWallet(testMnemonic, "", 1)
+	require.NoError(t, err)
+	signers, err := wallet.Signers(0, count)
+	require.NoError(t, err)
+	return signers
+}
+
+func TestMultiSig(t *testing.T) {
+	signers := newMultiSigTestSigners(t, 4)
+	owners := []common.Address{signers[0].Address(), signers[1].Address(), signers[2].Address()}
+
+	domain := createTestDomainWithContract("Treasury", "1", 1, testAddress2)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Pay 100")
+
+	ms, err := NewMultiSig(domain, types, "Mail", message, 2, owners)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ms.Threshold())
+
+	_, err = ms.Combined()
+	assert.ErrorIs(t, err, ErrThresholdNotMet)
+
+	// Add in descending address order; output must still be ascending
+	require.NoError(t, ms.Sign(signers[2]))
+	assert.False(t, ms.Ready())
+
+	sig, err := signers[0].SignTypedData(domain, types, "Mail", message)
+	require.NoError(t, err)
+	signer, err := ms.Add(sig)
+	require.NoError(t, err)
+	assert.Equal(t, signers[0].Address(), signer)
+	assert.True(t, ms.Ready())
+
+	_, err = ms.Add(sig)
+	assert.ErrorIs(t, err, ErrDuplicateSigner)
+
+	err = ms.Sign(signers[3])
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+
+	// A signature over other data is rejected
+	other, err := signers[1].SignTypedData(domain, types, "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Pay 1000"))
+	require.NoError(t, err)
+	_, err = ms.Add(other)
+	assert.Error(t, err)
+	assert.Equal(t, 2, ms.Count())
+
+	combined, err := ms.Combined()
+	require.NoError(t, err)
+	require.Len(t, combined, 130)
+
+	addresses := ms.Signers()
+	require.Len(t, addresses, 2)
+	assert.True(t, bytes.Compare(addresses[0][:], addresses[1][:]) < 0)
+
+	for i, addr := range addresses {
+		recovered, err := recoverHash(ms.Hash(), hexutil.Encode(combined[i*65:(i+1)*65]))
+		require.NoError(t, err)
+		assert.Equal(t, addr, recovered)
+	}
+}
+
+func TestMultiSigAnySigner(t *testing.T) {
+	signers := newMultiSigTestSigners(t, 3)
+
+	ms, err := NewMultiSig(createTestDomain("Test", "1", 1), createMailTypes(), "Mail",
+		createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"), 3, nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, signer := range signers {
+		wg.Add(1)
+		go func(signer *Signer) {
+			defer wg.Done()
+			assert.NoError(t, ms.Sign(signer))
+		}(signer)
+	}
+	wg.Wait()
+
+	assert.True(t, ms.Ready())
+	assert.Len(t, ms.Signatures(), 3)
+}
+
+func TestNewMultiSigErrors(t *testing.T) {
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+	owners := []common.Address{common.HexToAddress(testAddress1), common.HexToAddress(testAddress2)}
+
+	_, err := NewMultiSig(domain, types, "Mail", message, 0, owners)
+	assert.Error(t, err)
+
+	_, err = NewMultiSig(domain, types, "Mail", message, 3, owners)
+	assert.Error(t, err)
+
+	_, err = NewMultiSig(domain, types, "Mail", message, 1, append(owners, owners[0]))
+	assert.Error(t, err)
+
+	_, err = NewMultiSig(domain, map[string][]Type{"A": {{Name: "a", Type: "A"}}}, "A", Message{}, 1, owners)
+	assert.Error(t, err)
+}
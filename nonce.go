@@ -0,0 +1,373 @@
+package eip712
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// ErrReplay is returned when a message digest has already been consumed
+var ErrReplay = errors.New("message already consumed")
+
+// NonceMode selects how a NonceManager allocates nonces
+type NonceMode int
+
+const (
+	// NonceSequential allocates 0, 1, 2, ... per key, for contracts that
+	// track one incrementing nonce per account (EIP-2612)
+	NonceSequential NonceMode = iota
+	// NonceRandom allocates random 256-bit nonces, for contracts that accept
+	// unordered nonces (Permit2, CoW Protocol)
+	NonceRandom
+)
+
+// NonceKey scopes a nonce sequence to a signer, an EIP-712 domain and a
+// message type
+type NonceKey struct {
+	Signer common.Address
+	// Domain is the domain separator
+	Domain      common.Hash
+	PrimaryType string
+}
+
+// NewNonceKey returns the key of signer's nonces for primaryType messages
+// in domain
+func NewNonceKey(signer common.Address, domain Domain, primaryType string) (NonceKey, error) {
+	separator, err := NewFastTypedDataEncoder(domain, nil, primaryType, nil).DomainSeparator()
+	if err != nil {
+		return NonceKey{}, err
+	}
+	return NonceKey{Signer: signer, Domain: common.BytesToHash(separator), PrimaryType: primaryType}, nil
+}
+
+// String returns the key as signer/domain/primaryType
+func (k NonceKey) String() string {
+	return k.Signer.Hex() + "/" + k.Domain.Hex() + "/" + k.PrimaryType
+}
+
+// NonceManager allocates nonces for messages about to be signed.
+// Implementations are safe for concurrent use.
+type NonceManager interface {
+	// Next allocates the next nonce for key
+	Next(key NonceKey) (*big.Int, error)
+	// Set makes next the next sequential nonce for key, e.g. after reading
+	// the current nonce from the verifying contract
+	Set(key NonceKey, next *big.Int) error
+}
+
+// MemoryNonceManager keeps sequential nonces in memory
+type MemoryNonceManager struct {
+	mu     sync.Mutex
+	mode   NonceMode
+	nonces map[NonceKey]*big.Int
+}
+
+// NewMemoryNonceManager returns an in-memory nonce manager
+//
+// Example:
+//
+//	nonces := NewMemoryNonceManager(NonceSequential)
+//	key, _ := NewNonceKey(signer.Address(), domain, "Permit")
+//	nonce, _ := nonces.Next(key)
+//	message["nonce"] = nonce.String()
+func NewMemoryNonceManager(mode NonceMode) *MemoryNonceManager {
+	return &MemoryNonceManager{mode: mode, nonces: make(map[NonceKey]*big.Int)}
+}
+
+// Next allocates the next nonce for key
+func (m *MemoryNonceManager) Next(key NonceKey) (*big.Int, error) {
+	if m.mode == NonceRandom {
+		return randomNonce()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nonce := m.nonces[key]
+	if nonce == nil {
+		nonce = new(big.Int)
+	}
+	m.nonces[key] = new(big.Int).Add(nonce, common.Big1)
+	return nonce, nil
+}
+
+// Set makes next the next sequential nonce for key
+func (m *MemoryNonceManager) Set(key NonceKey, next *big.Int) error {
+	if err := checkNonce(next); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nonces[key] = new(big.Int).Set(next)
+	return nil
+}
+
+// FileNonceManager keeps sequential nonces in a JSON file, so allocation
+// survives restarts. The file is rewritten atomically on every allocation;
+// it must not be shared between processes.
+type FileNonceManager struct {
+	mu     sync.Mutex
+	path   string
+	mode   NonceMode
+	nonces map[string]*math.HexOrDecimal256
+}
+
+// NewFileNonceManager loads or creates the nonce file at path
+func NewFileNonceManager(path string, mode NonceMode) (*FileNonceManager, error) {
+	m := &FileNonceManager{path: path, mode: mode, nonces: make(map[string]*math.HexOrDecimal256)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return m, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read nonce file: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.nonces); err != nil {
+		return nil, fmt.Errorf("invalid nonce file %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Next allocates the next nonce for key and persists the allocation before
+// returning it
+func (m *FileNonceManager) Next(key NonceKey) (*big.Int, error) {
+	if m.mode == NonceRandom {
+		return randomNonce()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nonce := new(big.Int)
+	if stored := m.nonces[key.String()]; stored != nil {
+		nonce.Set((*big.Int)(stored))
+	}
+	if err := m.store(key, new(big.Int).Add(nonce, common.Big1)); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Set makes next the next sequential nonce for key
+func (m *FileNonceManager) Set(key NonceKey, next *big.Int) error {
+	if err := checkNonce(next); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.store(key, new(big.Int).Set(next))
+}
+
+// store records next for key and rewrites the file, restoring the previous
+// value if the write fails
+func (m *FileNonceManager) store(key NonceKey, next *big.Int) error {
+	name := key.String()
+	previous, existed := m.nonces[name]
+	m.nonces[name] = (*math.HexOrDecimal256)(next)
+
+	if err := m.save(); err != nil {
+		if existed {
+			m.nonces[name] = previous
+		} else {
+			delete(m.nonces, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the nonces to a temporary file and renames it over the
+// nonce file
+func (m *FileNonceManager) save() error {
+	data, err := json.MarshalIndent(m.nonces, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to write nonce file: %w", err)
+	}
+	return nil
+}
+
+// randomNonce returns a uniformly random 256-bit nonce
+func randomNonce() (*big.Int, error) {
+	nonce, err := rand.Int(rand.Reader, math.BigPow(2, 256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// checkNonce rejects values that do not fit a uint256 nonce
+func checkNonce(nonce *big.Int) error {
+	if nonce == nil || nonce.Sign() < 0 || nonce.BitLen() > 256 {
+		return fmt.Errorf("invalid nonce %v", nonce)
+	}
+	return nil
+}
+
+// ReplayGuard records the digests of messages a verifier has accepted and
+// rejects them if presented again within the retention window. Digests,
+// not signature bytes, are recorded, so a malleated signature over the same
+// message is still rejected. A ReplayGuard is safe for concurrent use.
+type ReplayGuard struct {
+	// Clock supplies the current time; nil means SystemClock. Set it
+	// before the guard is used.
+	Clock Clock
+
+	mu        sync.Mutex
+	retention time.Duration
+	seen      map[common.Hash]time.Time
+	// order lists digests in consumption order, for pruning; it stays
+	// empty when digests are kept forever
+	order []common.Hash
+}
+
+// NewReplayGuard returns a guard that remembers each digest for retention,
+// or forever if retention is zero or less. The window should cover the
+// longest validity (deadline) of the messages being verified. A guard
+// without retention grows by one entry per message consumed, so give it a
+// window unless the messages are few.
+//
+// Example:
+//
+//	guard := NewReplayGuard(24 * time.Hour)
+//	ok, err := guard.VerifyAndConsume(sig, owner, domain, types, "Order", message)
+//	if errors.Is(err, ErrReplay) {
+//	    // the order was already executed
+//	}
+func NewReplayGuard(retention time.Duration) *ReplayGuard {
+	return &ReplayGuard{
+		retention: retention,
+		seen:      make(map[common.Hash]time.Time),
+	}
+}
+
+// Consume records hash as used. It returns ErrReplay if hash was consumed
+// within the retention window.
+func (g *ReplayGuard) Consume(hash []byte) error {
+	if len(hash) != 32 {
+		return fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	digest := common.BytesToHash(hash)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	if _, ok := g.seen[digest]; ok {
+		return fmt.Errorf("%w: %s", ErrReplay, digest.Hex())
+	}
+	g.seen[digest] = now
+	if g.retention > 0 {
+		g.order = append(g.order, digest)
+	}
+	return nil
+}
+
+// Seen reports whether hash was consumed within the retention window
+func (g *ReplayGuard) Seen(hash []byte) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.prune(g.now())
+	_, ok := g.seen[common.BytesToHash(hash)]
+	return ok
+}
+
+// Len returns the number of digests retained
+func (g *ReplayGuard) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.prune(g.now())
+	return len(g.seen)
+}
+
+// VerifyAndConsume verifies sig like VerifySignatureFast and, if it is
+// valid, consumes the message digest. A valid signature over a message
+// already consumed returns false and ErrReplay.
+func (g *ReplayGuard) VerifyAndConsume(
+	sig *Signature,
+	expectedSigner common.Address,
+	domain Domain,
+	types map[string][]Type,
+	primaryType string,
+	message Message,
+) (bool, error) {
+	hash, err := NewFastTypedDataEncoder(domain, types, primaryType, message).Hash()
+	if err != nil {
+		return false, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	recovered, err := recoverHash(hash, sig.Bytes)
+	if err != nil {
+		return false, err
+	}
+	if recovered != expectedSigner {
+		return false, nil
+	}
+	if err := g.Consume(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// now reads the guard's clock
+func (g *ReplayGuard) now() time.Time {
+	if g.Clock == nil {
+		return SystemClock.Now()
+	}
+	return g.Clock.Now()
+}
+
+// prune forgets digests consumed before the retention window. Digests are
+// consumed in time order, so only the front of order needs checking.
+func (g *ReplayGuard) prune(now time.Time) {
+	if g.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-g.retention)
+
+	expired := 0
+	for _, digest := range g.order {
+		if g.seen[digest].After(cutoff) {
+			break
+		}
+		delete(g.seen, digest)
+		expired++
+	}
+	if expired > 0 {
+		g.order = g.order[expired:]
+	}
+}
@@ -0,0 +1,196 @@
+package eip712
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNonceKeys(t *testing.T) (NonceKey, NonceKey) {
+	domain := createTestDomainWithContract("USD Coin", "2", 1, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	permit, err := NewNonceKey(common.HexToAddress(testAddress1), domain, "Permit")
+	require.NoError(t, err)
+	other, err := NewNonceKey(common.HexToAddress(testAddress1), createTestDomain("USD Coin", "2", 137), "Permit")
+	require.NoError(t, err)
+	return permit, other
+}
+
+func TestNewNonceKey(t *testing.T) {
+	permit, other := testNonceKeys(t)
+	assert.NotEqual(t, permit, other)
+
+	domain := createTestDomainWithContract("USD Coin", "2", 1, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	separator, err := NewFastTypedDataEncoder(domain, createPermitTypes(), "Permit", nil).DomainSeparator()
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash(separator), permit.Domain)
+}
+
+func TestNonceManagers(t *testing.T) {
+	managers := map[string]func(t *testing.T) NonceManager{
+		"memory": func(t *testing.T) NonceManager { return NewMemoryNonceManager(NonceSequential) },
+		"file": func(t *testing.T) NonceManager {
+			m, err := NewFileNonceManager(filepath.Join(t.TempDir(), "nonces.json"), NonceSequential)
+			require.NoError(t, err)
+			return m
+		},
+	}
+
+	for name, newManager := range managers {
+		t.Run(name, func(t *testing.T) {
+			m := newManager(t)
+			permit, other := testNonceKeys(t)
+
+			for want := int64(0); want < 3; want++ {
+				nonce, err := m.Next(permit)
+				require.NoError(t, err)
+				assert.Equal(t, want, nonce.Int64())
+			}
+			nonce, err := m.Next(other)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), nonce.Int64(), "keys have separate sequences")
+
+			require.NoError(t, m.Set(permit, big.NewInt(100)))
+			nonce, err = m.Next(permit)
+			require.NoError(t, err)
+			assert.Equal(t, int64(100), nonce.Int64())
+
+			assert.Error(t, m.Set(permit, big.NewInt(-1)))
+			assert.Error(t, m.Set(permit, nil))
+		})
+	}
+}
+
+func TestMemoryNonceManagerConcurrent(t *testing.T) {
+	m := NewMemoryNonceManager(NonceSequential)
+	key, _ := testNonceKeys(t)
+
+	const n = 200
+	nonces := make([]int64, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			nonce, err := m.Next(key)
+			require.NoError(t, err)
+			nonces[i] = nonce.Int64()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool, n)
+	for _, nonce := range nonces {
+		assert.False(t, seen[nonce], "nonce %d allocated twice", nonce)
+		seen[nonce] = true
+	}
+	assert.Len(t, seen, n)
+}
+
+func TestFileNonceManagerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.json")
+	key, _ := testNonceKeys(t)
+
+	m, err := NewFileNonceManager(path, NonceSequential)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err := m.Next(key)
+		require.NoError(t, err)
+	}
+
+	reopened, err := NewFileNonceManager(path, NonceSequential)
+	require.NoError(t, err)
+	nonce, err := reopened.Next(key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), nonce.Int64())
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = NewFileNonceManager(path, NonceSequential)
+	assert.Error(t, err)
+}
+
+func TestRandomNonces(t *testing.T) {
+	key, _ := testNonceKeys(t)
+	file, err := NewFileNonceManager(filepath.Join(t.TempDir(), "nonces.json"), NonceRandom)
+	require.NoError(t, err)
+
+	for _, m := range []NonceManager{NewMemoryNonceManager(NonceRandom), file} {
+		a, err := m.Next(key)
+		require.NoError(t, err)
+		b, err := m.Next(key)
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+		assert.LessOrEqual(t, a.BitLen(), 256)
+	}
+}
+
+func TestReplayGuard(t *testing.T) {
+	clock := &fixedClock{time.Unix(1700000000, 0)}
+	guard := NewReplayGuard(time.Hour)
+	guard.Clock = clock
+
+	first := common.HexToHash("0x01").Bytes()
+	second := common.HexToHash("0x02").Bytes()
+
+	require.NoError(t, guard.Consume(first))
+	assert.ErrorIs(t, guard.Consume(first), ErrReplay)
+	assert.True(t, guard.Seen(first))
+
+	clock.advance(30 * time.Minute)
+	require.NoError(t, guard.Consume(second))
+	assert.Equal(t, 2, guard.Len())
+
+	// The first digest leaves the window, the second is still inside it
+	clock.advance(31 * time.Minute)
+	assert.False(t, guard.Seen(first))
+	assert.ErrorIs(t, guard.Consume(second), ErrReplay)
+	assert.Equal(t, 1, guard.Len())
+	require.NoError(t, guard.Consume(first))
+
+	assert.Error(t, guard.Consume([]byte{1, 2, 3}))
+}
+
+func TestReplayGuardForever(t *testing.T) {
+	clock := &fixedClock{time.Unix(1700000000, 0)}
+	guard := NewReplayGuard(0)
+	guard.Clock = clock
+
+	hash := common.HexToHash("0x01").Bytes()
+	require.NoError(t, guard.Consume(hash))
+	clock.advance(24 * 365 * time.Hour)
+	assert.ErrorIs(t, guard.Consume(hash), ErrReplay)
+	assert.Equal(t, 1, guard.Len())
+	// Without pruning there is no consumption order to keep
+	assert.Empty(t, guard.order)
+}
+
+func TestReplayGuardVerifyAndConsume(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	guard := NewReplayGuard(time.Hour)
+
+	domain := createTestDomain("Exchange", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+	sig, err := signer.SignTypedData(domain, types, "Mail", message)
+	require.NoError(t, err)
+
+	valid, err := guard.VerifyAndConsume(sig, common.HexToAddress(testAddress2), domain, types, "Mail", message)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, 0, guard.Len(), "an invalid signature must not consume the message")
+
+	valid, err = guard.VerifyAndConsume(sig, signer.Address(), domain, types, "Mail", message)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = guard.VerifyAndConsume(sig, signer.Address(), domain, types, "Mail", message)
+	assert.ErrorIs(t, err, ErrReplay)
+	assert.False(t, valid)
+}
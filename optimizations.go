@@ -2,14 +2,13 @@ package eip712
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
@@ -51,60 +50,27 @@ func NewOptimizedSigner(privateKeyHex string, chainID int64) (*OptimizedSigner,
 	}, nil
 }
 
-// SignTypedDataOptimized signs typed data with performance optimizations
+// SignTypedDataOptimized signs typed data with performance optimizations.
+// Policies set on the embedded Signer apply as they do to SignTypedData.
 func (s *OptimizedSigner) SignTypedDataOptimized(domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
-	// Validate for cyclic structures (cached internally)
-	if err := validateNoCycles(types); err != nil {
+	data := TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message}
+	if err := s.checkPolicies(data); err != nil {
 		return nil, err
 	}
 	
-	// Pre-allocate the typed data structure with capacity hints
-	typedData := apitypes.TypedData{
-		Types:       make(apitypes.Types, len(types)+1), // +1 for EIP712Domain
-		PrimaryType: primaryType,
-		Domain:      s.domainToAPITypes(domain),
-		Message:     apitypes.TypedDataMessage(message),
-	}
-	
-	// Convert types with pre-allocation
-	for typeName, fields := range types {
-		apiTypes := make([]apitypes.Type, len(fields))
-		for i, field := range fields {
-			apiTypes[i] = apitypes.Type{
-				Name: field.Name,
-				Type: field.Type,
-			}
-		}
-		typedData.Types[typeName] = apiTypes
-	}
-	
-	// Add EIP712Domain type if not present (with caching)
-	if _, ok := typedData.Types["EIP712Domain"]; !ok {
-		typedData.Types["EIP712Domain"] = s.getCachedDomainTypes(domain)
+	// Validate for cyclic structures (cached internally)
+	if err := validateNoCycles(types); err != nil {
+		return nil, err
 	}
 	
-	// Hash the typed data
-	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	// Hash the typed data, with cached domain types
+	hash, err := typedDataHash(domain, types, primaryType, message, s.getCachedDomainTypes(domain))
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash typed data: %w", err)
 	}
 	
 	// Sign the hash
-	signature, err := crypto.Sign(hash, s.privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
-	}
-	
-	// Transform V from 0/1 to 27/28 per Ethereum convention
-	signature[64] += 27
-	
-	return &Signature{
-		R:     hexutil.Encode(signature[:32]),
-		S:     hexutil.Encode(signature[32:64]),
-		V:     uint8(signature[64]),
-		Hash:  hexutil.Encode(hash),
-		Bytes: hexutil.Encode(signature),
-	}, nil
+	return s.signDigest(context.Background(), data, hash)
 }
 
 // getCachedDomainTypes returns cached domain types or builds and caches them
@@ -138,6 +104,7 @@ func domainCacheKey(domain Domain) string {
 		bufferPool.Put(buf)
 	}()
 	
+	buf.WriteByte(byte(domain.FieldSet()))
 	buf.WriteString(domain.Name)
 	buf.WriteByte('|')
 	buf.WriteString(domain.Version)
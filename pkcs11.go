@@ -0,0 +1,225 @@
+//go:build pkcs11
+
+package eip712
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+)
+
+// secp256k1OID is the DER-encoded curve OID 1.3.132.0.10 found in CKA_EC_PARAMS
+var secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+// PKCS11Config locates a secp256k1 key pair on a PKCS#11 token
+type PKCS11Config struct {
+	// Module is the path of the PKCS#11 library, e.g.
+	// /usr/lib/softhsm/libsofthsm2.so
+	Module string
+	// TokenLabel selects the token holding the key
+	TokenLabel string
+	// PIN is the user PIN of the token
+	PIN string
+	// KeyLabel is the CKA_LABEL shared by the private and public key objects
+	KeyLabel string
+}
+
+// PKCS11Signer is a KeySigner backed by a secp256k1 key on a PKCS#11 token.
+// The key never leaves the token. It is safe for concurrent use; signatures
+// are serialized over a single session.
+type PKCS11Signer struct {
+	mu         sync.Mutex
+	ctx        *pkcs11.Ctx
+	session    pkcs11.SessionHandle
+	privateKey pkcs11.ObjectHandle
+	address    common.Address
+}
+
+// NewPKCS11Signer opens a session on the configured token, logs in and finds
+// the key pair labelled cfg.KeyLabel. Close the signer to log out and unload
+// the module.
+func NewPKCS11Signer(cfg PKCS11Config) (*PKCS11Signer, error) {
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+
+	s := &PKCS11Signer{ctx: ctx}
+	if err := s.open(cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open logs in to the token and loads the key pair
+func (s *PKCS11Signer) open(cfg PKCS11Config) error {
+	slot, err := findPKCS11Slot(s.ctx, cfg.TokenLabel)
+	if err != nil {
+		return err
+	}
+
+	s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open PKCS#11 session: %w", err)
+	}
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, cfg.PIN); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return fmt.Errorf("failed to log in to PKCS#11 token: %w", err)
+	}
+
+	s.privateKey, err = s.findKey(pkcs11.CKO_PRIVATE_KEY, cfg.KeyLabel)
+	if err != nil {
+		return err
+	}
+	publicKey, err := s.findKey(pkcs11.CKO_PUBLIC_KEY, cfg.KeyLabel)
+	if err != nil {
+		return err
+	}
+
+	attrs, err := s.ctx.GetAttributeValue(s.session, publicKey, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read public key %q: %w", cfg.KeyLabel, err)
+	}
+	if !bytes.Equal(attrs[0].Value, secp256k1OID) {
+		return fmt.Errorf("key %q is not a secp256k1 key", cfg.KeyLabel)
+	}
+
+	point := attrs[1].Value
+	// CKA_EC_POINT is a DER OCTET STRING, though some tokens return the raw point
+	var unwrapped []byte
+	if rest, err := asn1.Unmarshal(point, &unwrapped); err == nil && len(rest) == 0 {
+		point = unwrapped
+	}
+	pub, err := crypto.UnmarshalPubkey(point)
+	if err != nil {
+		return fmt.Errorf("invalid public key %q: %w", cfg.KeyLabel, err)
+	}
+	s.address = crypto.PubkeyToAddress(*pub)
+
+	return nil
+}
+
+// findPKCS11Slot returns the slot of the token with the given label
+func findPKCS11Slot(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if info.Label == label {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("PKCS#11 token %q not found", label)
+}
+
+// findKey returns the single EC key object of the given class and label
+func (s *PKCS11Signer) findKey(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("key %q not found", label)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("key label %q is ambiguous", label)
+	}
+}
+
+// Address returns the Ethereum address of the token key
+func (s *PKCS11Signer) Address() common.Address {
+	return s.address
+}
+
+// SignHash signs a 32-byte digest on the token with CKM_ECDSA and returns a
+// low-S [R || S || V] signature
+func (s *PKCS11Signer) SignHash(hash []byte) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext is SignHash with a context. A PKCS#11 call cannot be
+// interrupted, so ctx is only checked once the session is free.
+func (s *PKCS11Signer) SignHashContext(ctx context.Context, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+
+	s.mu.Lock()
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if s.ctx == nil {
+		s.mu.Unlock()
+		return nil, ErrSignerClosed
+	}
+	err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.privateKey)
+	var raw []byte
+	if err == nil {
+		raw, err = s.ctx.Sign(s.session, hash)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %w", err)
+	}
+
+	// CKM_ECDSA returns r || s, though some tokens return DER
+	r, sv, err := parseRawSignature(raw)
+	if err != nil {
+		if r, sv, err = parseDERSignature(raw); err != nil {
+			return nil, err
+		}
+	}
+	return recoverableSignature(hash, r, sv, s.address)
+}
+
+// Close logs out, closes the session and unloads the module
+func (s *PKCS11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return nil
+	}
+	if s.session != 0 {
+		s.ctx.Logout(s.session)
+		s.ctx.CloseSession(s.session)
+	}
+	err := s.ctx.Finalize()
+	s.ctx.Destroy()
+	s.ctx = nil
+	return err
+}
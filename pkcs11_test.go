@@ -0,0 +1,112 @@
+//go:build pkcs11
+
+package eip712
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run against SoftHSM with:
+//
+//	softhsm2-util --init-token --free --label eip712 --pin 1234 --so-pin 5678
+//	PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so PKCS11_TOKEN_LABEL=eip712 PKCS11_PIN=1234 \
+//	    go test -tags pkcs11 -run PKCS11 .
+func pkcs11TestConfig(t *testing.T) PKCS11Config {
+	cfg := PKCS11Config{
+		Module:     os.Getenv("PKCS11_MODULE"),
+		TokenLabel: os.Getenv("PKCS11_TOKEN_LABEL"),
+		PIN:        os.Getenv("PKCS11_PIN"),
+	}
+	if cfg.Module == "" || cfg.TokenLabel == "" {
+		t.Skip("PKCS11_MODULE and PKCS11_TOKEN_LABEL not set")
+	}
+	return cfg
+}
+
+// generatePKCS11Key creates a secp256k1 key pair on the token and removes it
+// when the test ends
+func generatePKCS11Key(t *testing.T, cfg PKCS11Config, label string) {
+	ctx := pkcs11.New(cfg.Module)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+
+	slot, err := findPKCS11Slot(ctx, cfg.TokenLabel)
+	require.NoError(t, err)
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_USER, cfg.PIN))
+
+	public, private, err := ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		ctx.DestroyObject(session, public)
+		ctx.DestroyObject(session, private)
+		ctx.Logout(session)
+		ctx.CloseSession(session)
+		ctx.Finalize()
+		ctx.Destroy()
+	})
+}
+
+func TestPKCS11Signer(t *testing.T) {
+	cfg := pkcs11TestConfig(t)
+	cfg.KeyLabel = fmt.Sprintf("eip712-test-%d", time.Now().UnixNano())
+	generatePKCS11Key(t, cfg, cfg.KeyLabel)
+
+	hsm, err := NewPKCS11Signer(cfg)
+	require.NoError(t, err)
+
+	signer, err := NewSignerWithKeySigner(hsm, 1)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	domain := createTestDomain("Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	for i := 0; i < 8; i++ {
+		sig, err := signer.SignTypedData(domain, types, "Mail", message)
+		require.NoError(t, err)
+
+		valid, err := VerifySignature(sig, hsm.Address(), domain, types, "Mail", message)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestPKCS11SignerErrors(t *testing.T) {
+	cfg := pkcs11TestConfig(t)
+
+	cfg.KeyLabel = "missing-key"
+	_, err := NewPKCS11Signer(cfg)
+	assert.Error(t, err)
+
+	cfg.TokenLabel = "missing-token"
+	_, err = NewPKCS11Signer(cfg)
+	assert.Error(t, err)
+
+	_, err = NewPKCS11Signer(PKCS11Config{Module: "/nonexistent/libpkcs11.so"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,292 @@
+package eip712
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// ErrPolicyDenied is returned when a Policy refuses to let a message be
+// signed. The error chain also holds the *PolicyDenial with the reason.
+var ErrPolicyDenied = errors.New("signing denied by policy")
+
+// unlimitedApproval is the smallest amount treated as an unlimited approval:
+// type(uint160).max, the Permit2 maximum, which is far above any real
+// token amount
+var unlimitedApproval = new(big.Int).Sub(math.BigPow(2, 160), common.Big1)
+
+// Policy decides whether a Signer may sign typed data. Check returns nil to
+// allow it, or an error, normally a *PolicyDenial, to refuse it.
+type Policy interface {
+	Check(data TypedData) error
+}
+
+// PolicyFunc adapts a function to a Policy
+type PolicyFunc func(data TypedData) error
+
+// Check calls f(data)
+func (f PolicyFunc) Check(data TypedData) error {
+	return f(data)
+}
+
+// PolicyDenial is the reasoned refusal of a policy
+type PolicyDenial struct {
+	// Policy names the policy that refused
+	Policy string
+	Reason string
+}
+
+func (d *PolicyDenial) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrPolicyDenied, d.Policy, d.Reason)
+}
+
+// Is makes errors.Is(err, ErrPolicyDenied) match a PolicyDenial
+func (d *PolicyDenial) Is(target error) bool {
+	return target == ErrPolicyDenied
+}
+
+// deny returns a PolicyDenial from policy with a formatted reason
+func deny(policy, format string, args ...interface{}) error {
+	return &PolicyDenial{Policy: policy, Reason: fmt.Sprintf(format, args...)}
+}
+
+// SetPolicy makes the signer check every message against policy before
+// signing it; nil removes the policy. Combine several policies with
+// AllPolicies. It is safe to call while the signer is in use.
+//
+// Example:
+//
+//	signer.SetPolicy(AllPolicies(
+//	    AllowChainIDs(1),
+//	    AllowVerifyingContracts(usdc, dai),
+//	    ForPrimaryType("Permit", AllowSpenders(router)),
+//	    DenyUnlimitedApprovals("value"),
+//	))
+func (s *Signer) SetPolicy(policy Policy) {
+	s.key.mu.Lock()
+	defer s.key.mu.Unlock()
+	s.policy = policy
+}
+
+// checkPolicies applies the signer's chain check, expiry policy and policy
+// to typed data about to be signed
+func (s *Signer) checkPolicies(data TypedData) error {
+	if err := s.checkChain(data); err != nil {
+		return err
+	}
+	s.key.mu.RLock()
+	expiry, policy := s.expiry, s.policy
+	s.key.mu.RUnlock()
+	return checkExpiryAndPolicy(data, expiry, policy)
+}
+
+// SetPolicy makes the signer check every message against policy before
+// sending it to the external signer; nil removes the policy. It is safe to
+// call while the signer is in use.
+func (s *ExternalSigner) SetPolicy(policy Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+}
+
+// checkPolicies applies the signer's chain check, expiry policy and policy
+// to typed data about to be sent for signing, as Signer.checkPolicies does
+func (s *ExternalSigner) checkPolicies(data TypedData) error {
+	s.mu.RLock()
+	enforce, expiry, policy := s.enforceChain, s.expiry, s.policy
+	s.mu.RUnlock()
+	if enforce {
+		if err := checkChainID(data, s.chainID); err != nil {
+			return err
+		}
+	}
+	return checkExpiryAndPolicy(data, expiry, policy)
+}
+
+// checkExpiryAndPolicy applies an expiry policy and a policy, either of
+// which may be nil
+func checkExpiryAndPolicy(data TypedData, expiry *ExpiryPolicy, policy Policy) error {
+	if expiry != nil {
+		if err := expiry.Check(data); err != nil {
+			return err
+		}
+	}
+	if policy != nil {
+		return policy.Check(data)
+	}
+	return nil
+}
+
+// AllPolicies allows typed data only if every policy allows it, and
+// returns the first denial
+func AllPolicies(policies ...Policy) Policy {
+	return PolicyFunc(func(data TypedData) error {
+		for _, policy := range policies {
+			if err := policy.Check(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AnyPolicy allows typed data if at least one policy allows it. If all
+// deny, the denial lists every reason.
+func AnyPolicy(policies ...Policy) Policy {
+	return PolicyFunc(func(data TypedData) error {
+		reasons := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			err := policy.Check(data)
+			if err == nil {
+				return nil
+			}
+			reasons = append(reasons, err.Error())
+		}
+		return deny("any", "no policy allowed the message (%s)", strings.Join(reasons, "; "))
+	})
+}
+
+// ForPrimaryType applies policy only to messages of the given primary type
+// and allows all others
+func ForPrimaryType(primaryType string, policy Policy) Policy {
+	return PolicyFunc(func(data TypedData) error {
+		if data.PrimaryType != primaryType {
+			return nil
+		}
+		return policy.Check(data)
+	})
+}
+
+// DenyPrimaryTypesExcept allows only messages of the listed primary types
+func DenyPrimaryTypesExcept(primaryTypes ...string) Policy {
+	allowed := make(map[string]bool, len(primaryTypes))
+	for _, primaryType := range primaryTypes {
+		allowed[primaryType] = true
+	}
+	return PolicyFunc(func(data TypedData) error {
+		if !allowed[data.PrimaryType] {
+			return deny("primary type allowlist", "%s is not allowed", data.PrimaryType)
+		}
+		return nil
+	})
+}
+
+// AllowVerifyingContracts allows only domains whose verifyingContract is
+// one of contracts. A domain whose hashed fields omit verifyingContract is
+// denied, even if Domain.VerifyingContract is set.
+func AllowVerifyingContracts(contracts ...common.Address) Policy {
+	allowed := make(map[common.Address]bool, len(contracts))
+	for _, contract := range contracts {
+		allowed[contract] = true
+	}
+	return PolicyFunc(func(data TypedData) error {
+		if !data.Domain.hashedFields(data.Types).Has(DomainFieldVerifyingContract) {
+			return deny("verifying contract allowlist", "domain has no verifyingContract")
+		}
+		if !allowed[data.Domain.VerifyingContract] {
+			return deny("verifying contract allowlist", "%s is not allowed", data.Domain.VerifyingContract.Hex())
+		}
+		return nil
+	})
+}
+
+// AllowChainIDs allows only domains whose chainId is one of chainIDs. A
+// domain whose hashed fields omit chainId is denied.
+func AllowChainIDs(chainIDs ...int64) Policy {
+	allowed := make(map[string]bool, len(chainIDs))
+	for _, chainID := range chainIDs {
+		allowed[big.NewInt(chainID).String()] = true
+	}
+	return PolicyFunc(func(data TypedData) error {
+		if !data.Domain.hashedFields(data.Types).Has(DomainFieldChainID) || data.Domain.ChainID == nil {
+			return deny("chain allowlist", "domain has no chainId")
+		}
+		if !allowed[data.Domain.ChainID.String()] {
+			return deny("chain allowlist", "%s is not allowed", DefaultChainRegistry.Describe(data.Domain.ChainID))
+		}
+		return nil
+	})
+}
+
+// AllowSpenders allows a message only if its "spender" field, as in
+// EIP-2612 and Permit2 permits, is one of spenders. Messages without the
+// field are allowed; scope the policy with ForPrimaryType to require it.
+func AllowSpenders(spenders ...common.Address) Policy {
+	return AllowAddresses("spender", spenders...)
+}
+
+// AllowAddresses allows a message only if the address at field, a dotted
+// path, is one of addresses. Messages without the field are allowed.
+func AllowAddresses(field string, addresses ...common.Address) Policy {
+	allowed := make(map[common.Address]bool, len(addresses))
+	for _, address := range addresses {
+		allowed[address] = true
+	}
+	name := field + " allowlist"
+	return PolicyFunc(func(data TypedData) error {
+		value, ok := lookupField(data.Message, field)
+		if !ok {
+			return nil
+		}
+		address, err := toAddress(value)
+		if err != nil {
+			return deny(name, "invalid %s: %v", field, err)
+		}
+		if !allowed[address] {
+			return deny(name, "%s %s is not allowed", field, address.Hex())
+		}
+		return nil
+	})
+}
+
+// MaxValue denies messages whose integer at field, a dotted path, exceeds
+// max. Messages without the field are allowed.
+func MaxValue(field string, max *big.Int) Policy {
+	max = new(big.Int).Set(max)
+	name := field + " cap"
+	return PolicyFunc(func(data TypedData) error {
+		value, ok, err := policyInt(data.Message, field)
+		if err != nil {
+			return deny(name, "%v", err)
+		}
+		if ok && value.Cmp(max) > 0 {
+			return deny(name, "%s %s exceeds %s", field, value, max)
+		}
+		return nil
+	})
+}
+
+// DenyUnlimitedApprovals denies messages in which any of fields, dotted
+// paths such as "value" or "details.amount", holds an unlimited amount:
+// type(uint160).max or more, which covers type(uint256).max approvals
+func DenyUnlimitedApprovals(fields ...string) Policy {
+	return PolicyFunc(func(data TypedData) error {
+		for _, field := range fields {
+			value, ok, err := policyInt(data.Message, field)
+			if err != nil {
+				return deny("unlimited approval", "%v", err)
+			}
+			if ok && value.Cmp(unlimitedApproval) >= 0 {
+				return deny("unlimited approval", "%s is an unlimited amount", field)
+			}
+		}
+		return nil
+	})
+}
+
+// policyInt reads the integer at field; ok is false if the field is absent
+func policyInt(message Message, field string) (*big.Int, bool, error) {
+	value, ok := lookupField(message, field)
+	if !ok {
+		return nil, false, nil
+	}
+	n, err := messageInt(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return n, true, nil
+}
@@ -0,0 +1,145 @@
+package eip712
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	permit := createTestPermit(testOwner, testRouter, big.NewInt(1000000), big.NewInt(0))
+	otherChain := permit
+	otherChain.Domain = createTestDomainWithContract("USD Coin", "2", 137, testUSDC.Hex())
+	noContract := permit
+	noContract.Domain = createTestDomain("USD Coin", "2", 1)
+
+	// The domain values are set but the explicit type leaves them unhashed
+	unhashedDomain := permit
+	unhashedDomain.Types = createPermitTypes()
+	unhashedDomain.Types["EIP712Domain"] = []Type{{Name: "name", Type: "string"}, {Name: "version", Type: "string"}}
+	otherSpender := createTestPermit(testOwner, testSpender, big.NewInt(1000000), big.NewInt(0))
+	unlimited := createTestPermit(testOwner, testRouter, math.MaxBig256, big.NewInt(0))
+	mail := TypedData{
+		Domain:      createTestDomain("Mail", "1", 1),
+		Types:       createMailTypes(),
+		PrimaryType: "Mail",
+		Message:     createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello"),
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		data   TypedData
+		allow  bool
+	}{
+		{"contract allowed", AllowVerifyingContracts(testUSDC), permit, true},
+		{"contract not allowed", AllowVerifyingContracts(testRouter), permit, false},
+		{"no verifying contract", AllowVerifyingContracts(testUSDC), noContract, false},
+		{"unhashed verifying contract", AllowVerifyingContracts(testUSDC), unhashedDomain, false},
+		{"chain allowed", AllowChainIDs(1, 10), permit, true},
+		{"chain not allowed", AllowChainIDs(1, 10), otherChain, false},
+		{"unhashed chain", AllowChainIDs(1), unhashedDomain, false},
+		{"spender allowed", AllowSpenders(testRouter), permit, true},
+		{"spender not allowed", AllowSpenders(testRouter), otherSpender, false},
+		{"no spender field", AllowSpenders(testRouter), mail, true},
+		{"nested address", AllowAddresses("to.wallet", common.HexToAddress(testAddress2)), mail, true},
+		{"nested address not allowed", AllowAddresses("from.wallet", common.HexToAddress(testAddress2)), mail, false},
+		{"under cap", MaxValue("value", big.NewInt(1000000)), permit, true},
+		{"over cap", MaxValue("value", big.NewInt(999999)), permit, false},
+		{"limited approval", DenyUnlimitedApprovals("value"), permit, true},
+		{"unlimited approval", DenyUnlimitedApprovals("value"), unlimited, false},
+		{"primary type allowed", DenyPrimaryTypesExcept("Permit"), permit, true},
+		{"primary type not allowed", DenyPrimaryTypesExcept("Permit"), mail, false},
+		{"scoped to other type", ForPrimaryType("Mail", AllowSpenders()), permit, true},
+		{"scoped to type", ForPrimaryType("Permit", AllowSpenders()), permit, false},
+		{"all allow", AllPolicies(AllowChainIDs(1), AllowSpenders(testRouter)), permit, true},
+		{"all, one denies", AllPolicies(AllowChainIDs(1), AllowSpenders(testRouter)), otherSpender, false},
+		{"any, one allows", AnyPolicy(AllowChainIDs(137), AllowSpenders(testRouter)), permit, true},
+		{"any, none allow", AnyPolicy(AllowChainIDs(137), AllowSpenders()), permit, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.data)
+			if tt.allow {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrPolicyDenied)
+			var denial *PolicyDenial
+			require.True(t, errors.As(err, &denial))
+			assert.NotEmpty(t, denial.Policy)
+			assert.NotEmpty(t, denial.Reason)
+		})
+	}
+}
+
+func TestPolicyDenialReason(t *testing.T) {
+	err := AllowSpenders(testRouter).Check(createTestPermit(testOwner, testSpender, big.NewInt(1), big.NewInt(0)))
+	assert.EqualError(t, err, "signing denied by policy: spender allowlist: spender "+
+		common.HexToAddress(testAddress2).Hex()+" is not allowed")
+
+	err = MaxValue("value", big.NewInt(1)).Check(TypedData{Message: Message{"value": "lots"}})
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+	assert.ErrorContains(t, err, "invalid value")
+}
+
+func TestSignerPolicy(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	signer.SetPolicy(AllPolicies(
+		AllowChainIDs(1),
+		AllowVerifyingContracts(testUSDC),
+		ForPrimaryType("Permit", AllowSpenders(testRouter)),
+		DenyUnlimitedApprovals("value"),
+	))
+
+	deadline := big.NewInt(1893456000)
+	_, err = signer.SignPermit(testUSDC, "USD Coin", "2", testRouter, big.NewInt(1000000), big.NewInt(0), deadline)
+	assert.NoError(t, err)
+
+	_, err = signer.SignPermit(testUSDC, "USD Coin", "2", common.HexToAddress(testAddress2), big.NewInt(1000000), big.NewInt(0), deadline)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	_, err = signer.SignPermit(testUSDC, "USD Coin", "2", testRouter, math.MaxBig256, big.NewInt(0), deadline)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	_, err = signer.SignPermit(testRouter, "USD Coin", "2", testRouter, big.NewInt(1), big.NewInt(0), deadline)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	_, err = signer.SignSeaportOrder(createTestSeaportOrder(1), SeaportV16Address)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	signer.SetPolicy(nil)
+	_, err = signer.SignSeaportOrder(createTestSeaportOrder(1), SeaportV16Address)
+	assert.NoError(t, err)
+}
+
+func TestOptimizedSignerPolicy(t *testing.T) {
+	signer, err := NewOptimizedSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	permit := createTestPermit(testOwner, testRouter, big.NewInt(1), big.NewInt(0))
+
+	signer.SetPolicy(AllowVerifyingContracts(testRouter))
+	_, err = signer.SignTypedDataOptimized(permit.Domain, permit.Types, permit.PrimaryType, permit.Message)
+	assert.ErrorIs(t, err, ErrPolicyDenied)
+
+	signer.SetPolicy(AllowVerifyingContracts(testUSDC))
+	_, err = signer.SignTypedDataOptimized(permit.Domain, permit.Types, permit.PrimaryType, permit.Message)
+	assert.NoError(t, err)
+}
+
+func TestExpiryPolicyComposes(t *testing.T) {
+	clock := &fixedClock{time.Unix(1893456000+10, 0)}
+	expiry := DefaultExpiryPolicy(0)
+	expiry.Clock = clock
+
+	policy := AllPolicies(AllowSpenders(testRouter), expiry)
+	assert.ErrorIs(t, policy.Check(createTestPermit(testOwner, testRouter, big.NewInt(1), big.NewInt(0))), ErrExpired)
+}
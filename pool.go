@@ -0,0 +1,178 @@
+package eip712
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SignerPool signs concurrently with one key. Each request is deep-copied
+// before encoding, so callers may share messages, including *big.Int
+// values, across goroutines, and every worker encodes with its own
+// FastTypedDataEncoder. A SignerPool is safe for concurrent use.
+type SignerPool struct {
+	signer   *Signer
+	workers  int
+	encoders sync.Pool
+}
+
+// NewSignerPool returns a pool signing with signer's key. workers bounds the
+// concurrency of SignBatch; zero or less uses GOMAXPROCS.
+//
+// Example:
+//
+//	pool := NewSignerPool(signer, 8)
+//	sigs, err := pool.SignBatch(ctx, orders)
+func NewSignerPool(signer *Signer, workers int) *SignerPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &SignerPool{
+		signer:  signer,
+		workers: workers,
+		encoders: sync.Pool{
+			New: func() interface{} {
+				return &FastTypedDataEncoder{cache: globalEncoderCache}
+			},
+		},
+	}
+}
+
+// Address returns the address of the pool's key
+func (p *SignerPool) Address() common.Address {
+	return p.signer.Address()
+}
+
+// Workers returns the maximum number of concurrent SignBatch workers
+func (p *SignerPool) Workers() int {
+	return p.workers
+}
+
+// SignTypedData signs typed data; it may be called from any goroutine
+func (p *SignerPool) SignTypedData(domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	return p.SignTypedDataContext(context.Background(), domain, types, primaryType, message)
+}
+
+// SignTypedDataContext is SignTypedData with a context
+func (p *SignerPool) SignTypedDataContext(ctx context.Context, domain Domain, types map[string][]Type, primaryType string, message Message) (*Signature, error) {
+	return p.sign(ctx, TypedData{Domain: domain, Types: types, PrimaryType: primaryType, Message: message})
+}
+
+// SignBatch signs every item concurrently and returns the signatures in item
+// order. It stops at the first failure and returns an error naming the item.
+// FastSigner.SignEach is the variant that reports every item's outcome.
+func (p *SignerPool) SignBatch(ctx context.Context, items []TypedData) ([]*Signature, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signatures := make([]*Signature, len(items))
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	runBatch(len(items), p.workers, func(encoder *FastTypedDataEncoder, i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		sig, err := p.signWith(ctx, encoder, items[i])
+		if err != nil {
+			errOnce.Do(func() {
+				firstErr = fmt.Errorf("item %d: %w", i, err)
+				cancel()
+			})
+			return
+		}
+		signatures[i] = sig
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+// sign signs data with a pooled encoder
+func (p *SignerPool) sign(ctx context.Context, data TypedData) (*Signature, error) {
+	encoder := p.encoders.Get().(*FastTypedDataEncoder)
+	defer p.encoders.Put(encoder)
+	return p.signWith(ctx, encoder, data)
+}
+
+// signWith hashes a private copy of data with encoder and signs it
+func (p *SignerPool) signWith(ctx context.Context, encoder *FastTypedDataEncoder, data TypedData) (*Signature, error) {
+	data = data.clone()
+	if err := p.signer.checkPolicies(data); err != nil {
+		return nil, err
+	}
+
+	encoder.reset(data.Domain, data.Types, data.PrimaryType, data.Message)
+	hash, err := encoder.HashContext(ctx)
+	encoder.reset(Domain{}, nil, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	return p.signer.signDigest(ctx, data, hash)
+}
+
+// clone returns a copy of the typed data whose domain and message share no
+// mutable values with the original. Types are only read and are shared.
+func (td TypedData) clone() TypedData {
+	if td.Domain.ChainID != nil {
+		td.Domain.ChainID = new(big.Int).Set(td.Domain.ChainID)
+	}
+	if td.Message != nil {
+		td.Message = cloneValue(map[string]interface{}(td.Message)).(map[string]interface{})
+	}
+	return td
+}
+
+// cloneValue deep-copies maps, slices, *big.Int and []byte message values
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = cloneValue(item)
+		}
+		return out
+	case Message:
+		return Message(cloneValue(map[string]interface{}(v)).(map[string]interface{}))
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = cloneValue(item)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			out[i] = cloneValue(item).(map[string]interface{})
+		}
+		return out
+	case *big.Int:
+		if v == nil {
+			return v
+		}
+		return new(big.Int).Set(v)
+	case []*big.Int:
+		out := make([]*big.Int, len(v))
+		for i, item := range v {
+			if item != nil {
+				out[i] = new(big.Int).Set(item)
+			}
+		}
+		return out
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,111 @@
+package eip712
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerPool(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	pool := NewSignerPool(signer, 4)
+
+	assert.Equal(t, signer.Address(), pool.Address())
+	assert.Equal(t, 4, pool.Workers())
+	assert.Greater(t, NewSignerPool(signer, 0).Workers(), 0)
+
+	domain := createTestDomain("Pool Test", "1", 1)
+	types := map[string][]Type{
+		"Transfer": {{Name: "to", Type: "address"}, {Name: "amount", Type: "uint256"}, {Name: "memo", Type: "bytes"}},
+	}
+
+	t.Run("matches Signer", func(t *testing.T) {
+		amount := big.NewInt(1_000_000)
+		message := Message{"to": testAddress2, "amount": amount, "memo": []byte{0xca, 0xfe}}
+
+		got, err := pool.SignTypedData(domain, types, "Transfer", message)
+		require.NoError(t, err)
+		want, err := signer.SignTypedData(domain, types, "Transfer", Message{"to": testAddress2, "amount": "1000000", "memo": "0xcafe"})
+		require.NoError(t, err)
+
+		assert.Equal(t, want.Hash, got.Hash)
+		assert.Equal(t, want.Bytes, got.Bytes)
+		assert.Equal(t, int64(1_000_000), amount.Int64(), "message value must not be modified")
+	})
+
+	t.Run("does not modify inputs", func(t *testing.T) {
+		// A negative value is truncated in place by the 256-bit encoder
+		amount := big.NewInt(-1)
+		intTypes := map[string][]Type{"Delta": {{Name: "amount", Type: "int256"}}}
+
+		_, err := pool.SignTypedData(domain, intTypes, "Delta", Message{"amount": amount})
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), amount.Int64())
+	})
+
+	t.Run("batch preserves order", func(t *testing.T) {
+		items := make([]TypedData, 50)
+		for i := range items {
+			items[i] = TypedData{
+				Domain:      domain,
+				Types:       types,
+				PrimaryType: "Transfer",
+				Message:     Message{"to": testAddress2, "amount": big.NewInt(int64(i)), "memo": []byte{byte(i)}},
+			}
+		}
+
+		sigs, err := pool.SignBatch(context.Background(), items)
+		require.NoError(t, err)
+		require.Len(t, sigs, len(items))
+		for i, sig := range sigs {
+			want, err := pool.SignTypedData(items[i].Domain, items[i].Types, items[i].PrimaryType, items[i].Message)
+			require.NoError(t, err)
+			assert.Equal(t, want.Bytes, sig.Bytes, "item %d", i)
+		}
+	})
+
+	t.Run("batch error names the item", func(t *testing.T) {
+		items := make([]TypedData, 10)
+		for i := range items {
+			items[i] = TypedData{Domain: domain, Types: types, PrimaryType: "Transfer",
+				Message: Message{"to": testAddress2, "amount": fmt.Sprint(i), "memo": "0x"}}
+		}
+		items[7].Message = Message{"to": "not an address", "amount": "1", "memo": "0x"}
+
+		sigs, err := pool.SignBatch(context.Background(), items)
+		require.Error(t, err)
+		assert.Nil(t, sigs)
+		assert.Contains(t, err.Error(), "item 7")
+	})
+
+	t.Run("batch respects cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		items := []TypedData{{Domain: domain, Types: types, PrimaryType: "Transfer",
+			Message: Message{"to": testAddress2, "amount": "1", "memo": "0x"}}}
+		_, err := pool.SignBatch(ctx, items)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		sigs, err := pool.SignBatch(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, sigs)
+	})
+
+	t.Run("closed signer", func(t *testing.T) {
+		closed, err := NewSigner(testPrivateKey2, 1)
+		require.NoError(t, err)
+		require.NoError(t, closed.Close())
+
+		_, err = NewSignerPool(closed, 1).SignTypedData(domain, types, "Transfer",
+			Message{"to": testAddress2, "amount": "1", "memo": "0x"})
+		assert.ErrorIs(t, err, ErrSignerClosed)
+	})
+}
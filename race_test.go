@@ -3,7 +3,9 @@
 package eip712
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 
@@ -164,4 +166,138 @@ func TestConcurrentSignatureRecovery(t *testing.T) {
 	}
 	
 	wg.Wait()
-}
\ No newline at end of file
+}
+
+// TestConcurrentSignerPool shares one pool and the same *big.Int values
+// across goroutines
+func TestConcurrentSignerPool(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	pool := NewSignerPool(signer, 8)
+
+	domain := createTestDomain("Pool Race Test", "1", 1)
+	types := map[string][]Type{
+		"Order": {{Name: "id", Type: "uint256"}, {Name: "delta", Type: "int256"}, {Name: "items", Type: "uint256[]"}},
+	}
+	shared := Message{
+		"id":    big.NewInt(42),
+		"delta": big.NewInt(-7),
+		"items": []interface{}{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+	}
+
+	want, err := pool.SignTypedData(domain, types, "Order", shared)
+	require.NoError(t, err)
+
+	const numOperations = 100
+	var wg sync.WaitGroup
+	errs := make(chan error, numOperations)
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func() {
+			defer wg.Done()
+			sig, err := pool.SignTypedData(domain, types, "Order", shared)
+			if err == nil && sig.Bytes != want.Bytes {
+				err = fmt.Errorf("signature %s, want %s", sig.Bytes, want.Bytes)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int64(-7), shared["delta"].(*big.Int).Int64())
+}
+
+// TestConcurrentSignBatch runs batches over shared items from several
+// goroutines at once
+func TestConcurrentSignBatch(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey2, 1)
+	require.NoError(t, err)
+	pool := NewSignerPool(signer, 4)
+
+	domain := createTestDomain("Batch Race Test", "1", 1)
+	types := map[string][]Type{
+		"Message": {{Name: "id", Type: "uint256"}, {Name: "data", Type: "bytes"}},
+	}
+	amount := big.NewInt(1)
+	items := make([]TypedData, 32)
+	for i := range items {
+		items[i] = TypedData{
+			Domain:      domain,
+			Types:       types,
+			PrimaryType: "Message",
+			Message:     Message{"id": amount, "data": []byte{byte(i)}},
+		}
+	}
+
+	const numBatches = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, numBatches)
+	wg.Add(numBatches)
+	for i := 0; i < numBatches; i++ {
+		go func() {
+			defer wg.Done()
+			sigs, err := pool.SignBatch(context.Background(), items)
+			if err == nil && len(sigs) != len(items) {
+				err = fmt.Errorf("%d signatures, want %d", len(sigs), len(items))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+// TestConcurrentSignerSettings changes the audit sink and policies while
+// other goroutines sign
+func TestConcurrentSignerSettings(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	fastSigner, err := NewFastSigner(testPrivateKey2, 1)
+	require.NoError(t, err)
+
+	domain := createTestDomain("Settings Race Test", "1", 1)
+	types := createMailTypes()
+	message := createMailMessage("Alice", testAddress1, "Bob", testAddress2, "Hello")
+
+	const numOperations = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*numOperations)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numOperations; i++ {
+			sink := &memoryAuditSink{}
+			signer.SetAuditSink(sink)
+			fastSigner.SetAuditSink(sink)
+			signer.SetPolicy(AllowChainIDs(1))
+			signer.SetExpiryPolicy(nil)
+			signer.SetEnforceChain(i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numOperations; i++ {
+			_, err := signer.SignTypedData(domain, types, "Mail", message)
+			errs <- err
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numOperations; i++ {
+			_, err := fastSigner.SignTypedDataFast(domain, types, "Mail", message)
+			errs <- err
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
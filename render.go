@@ -0,0 +1,331 @@
+package eip712
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// DefaultTimestampFields are the field names rendered as dates
+var DefaultTimestampFields = []string{
+	"deadline", "sigDeadline", "expiry", "expiration",
+	"validAfter", "validBefore", "validFrom", "validUntil",
+	"startTime", "endTime", "decayStartTime", "decayEndTime",
+}
+
+// RenderOptions controls how values are rendered
+type RenderOptions struct {
+	// Decimals gives the token decimals of amount fields, keyed by dotted
+	// path (e.g. "details.amount", with array elements under their array's
+	// path) or by bare field name (e.g. "value")
+	Decimals map[string]int
+	// TimestampFields are the field names rendered as dates; nil means
+	// DefaultTimestampFields
+	TimestampFields []string
+	// Location is the time zone of rendered dates; nil means UTC
+	Location *time.Location
+}
+
+// RenderedField is one node of rendered typed data: a struct or array with
+// child Fields, or a leaf with a Value. Raw holds the original value of a
+// leaf whose Value was formatted, such as an amount or a timestamp.
+type RenderedField struct {
+	Name   string           `json:"name"`
+	Type   string           `json:"type"`
+	Value  string           `json:"value,omitempty"`
+	Raw    string           `json:"raw,omitempty"`
+	Fields []*RenderedField `json:"fields,omitempty"`
+}
+
+// Render walks message along the schema of primaryType and returns a
+// labelled tree for display, using the default options. Encode it with
+// encoding/json or print it with Text.
+//
+// Example:
+//
+//	tree, err := RenderWithOptions(types, "Permit", message, RenderOptions{
+//	    Decimals: map[string]int{"value": 6},
+//	})
+//	fmt.Print(tree.Text())
+//	// Permit
+//	//   owner: 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266
+//	//   spender: 0x70997970C51812dc3A010C7d01b50e0d17dc79C8
+//	//   value: 1.5 (1500000)
+//	//   nonce: 0
+//	//   deadline: 2030-01-01 00:00:00 UTC (1893456000)
+func Render(types map[string][]Type, primaryType string, message Message) (*RenderedField, error) {
+	return RenderWithOptions(types, primaryType, message, RenderOptions{})
+}
+
+// RenderWithOptions is Render with options
+func RenderWithOptions(types map[string][]Type, primaryType string, message Message, opts RenderOptions) (*RenderedField, error) {
+	if _, ok := types[primaryType]; !ok {
+		return nil, fmt.Errorf("primary type %s is not defined", primaryType)
+	}
+	if err := validateNoCycles(types); err != nil {
+		return nil, err
+	}
+
+	r := &renderer{types: types, opts: opts, timestamps: make(map[string]bool)}
+	if r.opts.TimestampFields == nil {
+		r.opts.TimestampFields = DefaultTimestampFields
+	}
+	for _, name := range r.opts.TimestampFields {
+		r.timestamps[name] = true
+	}
+	if r.opts.Location == nil {
+		r.opts.Location = time.UTC
+	}
+
+	return r.renderStruct(primaryType, primaryType, "", map[string]interface{}(message))
+}
+
+// RenderDomain renders the fields of domain that are part of its type, with
+// the chain named from DefaultChainRegistry
+func RenderDomain(domain Domain) *RenderedField {
+	node := &RenderedField{Name: "EIP712Domain", Type: "EIP712Domain"}
+	fields := domain.FieldSet()
+	if fields.Has(DomainFieldName) {
+		node.Fields = append(node.Fields, &RenderedField{Name: "name", Type: "string", Value: domain.Name})
+	}
+	if fields.Has(DomainFieldVersion) {
+		node.Fields = append(node.Fields, &RenderedField{Name: "version", Type: "string", Value: domain.Version})
+	}
+	if fields.Has(DomainFieldChainID) {
+		chainID := &RenderedField{Name: "chainId", Type: "uint256", Value: DefaultChainRegistry.Describe(domain.ChainID)}
+		if chain, ok := DefaultChainRegistry.Lookup(domain.ChainID); ok {
+			chainID.Value, chainID.Raw = chain.Name, domain.ChainID.String()
+		}
+		node.Fields = append(node.Fields, chainID)
+	}
+	if fields.Has(DomainFieldVerifyingContract) {
+		node.Fields = append(node.Fields, &RenderedField{Name: "verifyingContract", Type: "address", Value: domain.VerifyingContract.Hex()})
+	}
+	if fields.Has(DomainFieldSalt) {
+		node.Fields = append(node.Fields, &RenderedField{Name: "salt", Type: "bytes32", Value: hexutil.Encode(domain.Salt[:])})
+	}
+	return node
+}
+
+// Text renders the tree as indented "name: value" lines
+func (f *RenderedField) Text() string {
+	var sb strings.Builder
+	f.writeText(&sb, 0)
+	return sb.String()
+}
+
+func (f *RenderedField) writeText(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	switch {
+	case f.Fields != nil:
+		if depth == 0 {
+			sb.WriteString(f.Name)
+		} else {
+			fmt.Fprintf(sb, "%s (%s):", f.Name, f.Type)
+		}
+	case f.Raw != "":
+		fmt.Fprintf(sb, "%s: %s (%s)", f.Name, f.Value, f.Raw)
+	default:
+		fmt.Fprintf(sb, "%s: %s", f.Name, f.Value)
+	}
+	sb.WriteByte('\n')
+
+	for _, child := range f.Fields {
+		child.writeText(sb, depth+1)
+	}
+}
+
+// renderer holds the state of one Render call
+type renderer struct {
+	types      map[string][]Type
+	opts       RenderOptions
+	timestamps map[string]bool
+}
+
+// renderValue renders value of fieldType; path is its dotted path, with
+// array indices dropped, for Decimals lookups
+func (r *renderer) renderValue(name, fieldType, path string, value interface{}) (*RenderedField, error) {
+	if isArrayType(fieldType) {
+		return r.renderArray(name, fieldType, path, value)
+	}
+	if _, ok := r.types[fieldType]; ok {
+		var data map[string]interface{}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			data = v
+		case Message:
+			data = v
+		default:
+			return nil, fmt.Errorf("%s: invalid struct value type: %T", path, value)
+		}
+		return r.renderStruct(name, fieldType, path, data)
+	}
+
+	node := &RenderedField{Name: name, Type: fieldType}
+	if err := r.renderPrimitive(node, path, value); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return node, nil
+}
+
+func (r *renderer) renderStruct(name, typeName, path string, data map[string]interface{}) (*RenderedField, error) {
+	node := &RenderedField{Name: name, Type: typeName, Fields: []*RenderedField{}}
+	for _, field := range r.types[typeName] {
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing field", fieldPath)
+		}
+		child, err := r.renderValue(field.Name, field.Type, fieldPath, value)
+		if err != nil {
+			return nil, err
+		}
+		node.Fields = append(node.Fields, child)
+	}
+	return node, nil
+}
+
+func (r *renderer) renderArray(name, fieldType, path string, value interface{}) (*RenderedField, error) {
+	elementType, length, _, err := splitArrayType(fieldType)
+	if err != nil {
+		return nil, err
+	}
+	slice := reflect.ValueOf(value)
+	if slice.Kind() != reflect.Slice && slice.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s: expected slice for array type %s", path, fieldType)
+	}
+	if length > 0 && slice.Len() != length {
+		return nil, fmt.Errorf("%s: expected %d elements for array type %s, got %d", path, length, fieldType, slice.Len())
+	}
+
+	node := &RenderedField{Name: name, Type: fieldType, Fields: make([]*RenderedField, 0, slice.Len())}
+	for i := 0; i < slice.Len(); i++ {
+		child, err := r.renderValue(fmt.Sprintf("[%d]", i), elementType, path, slice.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		node.Fields = append(node.Fields, child)
+	}
+	return node, nil
+}
+
+func (r *renderer) renderPrimitive(node *RenderedField, path string, value interface{}) error {
+	switch fieldType := node.Type; {
+	case fieldType == "address":
+		address, err := toAddress(value)
+		if err != nil {
+			return err
+		}
+		node.Value = address.Hex()
+
+	case fieldType == "bool":
+		node.Value = fmt.Sprint(toBool(value))
+
+	case fieldType == "string":
+		node.Value = toString(value)
+
+	case strings.HasPrefix(fieldType, "bytes"):
+		b, err := renderBytes(value)
+		if err != nil {
+			return err
+		}
+		node.Value = hexutil.Encode(b)
+
+	case strings.HasPrefix(fieldType, "uint") || strings.HasPrefix(fieldType, "int"):
+		n, err := messageInt(value)
+		if err != nil {
+			return err
+		}
+		r.renderInteger(node, path, n)
+
+	default:
+		return fmt.Errorf("unsupported type: %s", fieldType)
+	}
+	return nil
+}
+
+// renderInteger renders a timestamp as a date, an amount with known
+// decimals as a decimal number, and a uint256 maximum as unlimited
+func (r *renderer) renderInteger(node *RenderedField, path string, n *big.Int) {
+	raw := n.String()
+	fieldName := path[strings.LastIndex(path, ".")+1:]
+	maxUint := node.Type == "uint256" && n.Cmp(math.MaxBig256) == 0
+
+	switch {
+	case r.timestamps[fieldName]:
+		node.Raw = raw
+		if maxUint || n.Cmp(maxUnixTime) > 0 {
+			node.Value = "never"
+		} else {
+			node.Value = time.Unix(n.Int64(), 0).In(r.opts.Location).Format("2006-01-02 15:04:05 MST")
+		}
+	case maxUint:
+		node.Raw = raw
+		node.Value = "unlimited"
+	default:
+		decimals, ok := r.opts.Decimals[path]
+		if !ok {
+			decimals, ok = r.opts.Decimals[fieldName]
+		}
+		if ok {
+			node.Raw = raw
+			node.Value = formatUnits(n, decimals)
+		} else {
+			node.Value = raw
+		}
+	}
+}
+
+// formatUnits formats an integer amount of the smallest unit as a decimal
+// number of whole tokens, e.g. 1500000 with 6 decimals as "1.5"
+func formatUnits(n *big.Int, decimals int) string {
+	if decimals <= 0 {
+		return n.String()
+	}
+
+	digits := new(big.Int).Abs(n).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	formatted := whole
+	if frac != "" {
+		formatted += "." + frac
+	}
+	if n.Sign() < 0 {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// renderBytes converts bytes and bytesN values, including fixed-size arrays
+func renderBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case [32]byte:
+		return v[:], nil
+	case common.Hash:
+		return v[:], nil
+	case string:
+		if !strings.HasPrefix(v, "0x") {
+			return []byte(v), nil
+		}
+		return hexutil.Decode(v)
+	default:
+		return toBytes(value)
+	}
+}
+
+// isArrayType reports whether an EIP-712 type is an array
+func isArrayType(fieldType string) bool {
+	return strings.HasSuffix(fieldType, "]")
+}
@@ -0,0 +1,140 @@
+package eip712
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPermit(t *testing.T) {
+	message := createPermitMessage(
+		strings.ToLower(testAddress1), strings.ToLower(testAddress2),
+		big.NewInt(1500000), big.NewInt(0), big.NewInt(1893456000),
+	)
+	tree, err := RenderWithOptions(createPermitTypes(), "Permit", message, RenderOptions{
+		Decimals: map[string]int{"value": 6},
+	})
+	require.NoError(t, err)
+
+	want := "Permit\n" +
+		"  owner: " + common.HexToAddress(testAddress1).Hex() + "\n" +
+		"  spender: " + common.HexToAddress(testAddress2).Hex() + "\n" +
+		"  value: 1.5 (1500000)\n" +
+		"  nonce: 0\n" +
+		"  deadline: 2030-01-01 00:00:00 UTC (1893456000)\n"
+	assert.Equal(t, want, tree.Text())
+
+	data, err := json.Marshal(tree)
+	require.NoError(t, err)
+	var decoded RenderedField
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, tree, &decoded)
+	assert.Contains(t, string(data), `{"name":"value","type":"uint256","value":"1.5","raw":"1500000"}`)
+}
+
+func TestRenderNested(t *testing.T) {
+	types := map[string][]Type{
+		"Order": {
+			{Name: "info", Type: "Info"},
+			{Name: "outputs", Type: "Output[]"},
+			{Name: "approval", Type: "uint256"},
+			{Name: "salt", Type: "bytes32"},
+			{Name: "active", Type: "bool"},
+			{Name: "memo", Type: "string"},
+		},
+		"Info":   {{Name: "swapper", Type: "address"}, {Name: "deadline", Type: "uint256"}},
+		"Output": {{Name: "recipient", Type: "address"}, {Name: "amount", Type: "uint256"}},
+	}
+	message := Message{
+		"info": map[string]interface{}{"swapper": testAddress1, "deadline": int64(1700000000)},
+		"outputs": []interface{}{
+			map[string]interface{}{"recipient": testAddress2, "amount": "2500000000000000000"},
+			map[string]interface{}{"recipient": testAddress1, "amount": big.NewInt(1)},
+		},
+		"approval": math.MaxBig256,
+		"salt":     common.HexToHash("0x2a"),
+		"active":   true,
+		"memo":     "hi",
+	}
+
+	tree, err := RenderWithOptions(types, "Order", message, RenderOptions{
+		Decimals: map[string]int{"outputs.amount": 18},
+		Location: time.FixedZone("UTC+2", 2*60*60),
+	})
+	require.NoError(t, err)
+
+	want := "Order\n" +
+		"  info (Info):\n" +
+		"    swapper: " + common.HexToAddress(testAddress1).Hex() + "\n" +
+		"    deadline: 2023-11-15 00:13:20 UTC+2 (1700000000)\n" +
+		"  outputs (Output[]):\n" +
+		"    [0] (Output):\n" +
+		"      recipient: " + common.HexToAddress(testAddress2).Hex() + "\n" +
+		"      amount: 2.5 (2500000000000000000)\n" +
+		"    [1] (Output):\n" +
+		"      recipient: " + common.HexToAddress(testAddress1).Hex() + "\n" +
+		"      amount: 0.000000000000000001 (1)\n" +
+		"  approval: unlimited (" + math.MaxBig256.String() + ")\n" +
+		"  salt: 0x000000000000000000000000000000000000000000000000000000000000002a\n" +
+		"  active: true\n" +
+		"  memo: hi\n"
+	assert.Equal(t, want, tree.Text())
+}
+
+func TestRenderErrors(t *testing.T) {
+	types := createPermitTypes()
+	message := createPermitMessage(testAddress1, testAddress2, big.NewInt(1), big.NewInt(0), big.NewInt(1))
+
+	_, err := Render(types, "Missing", message)
+	assert.Error(t, err)
+
+	delete(message, "nonce")
+	_, err = Render(types, "Permit", message)
+	assert.ErrorContains(t, err, "nonce")
+
+	message["nonce"] = "0"
+	message["spender"] = "not an address"
+	_, err = Render(types, "Permit", message)
+	assert.ErrorContains(t, err, "spender")
+
+	_, err = Render(map[string][]Type{"A": {{Name: "b", Type: "B"}}, "B": {{Name: "a", Type: "A"}}}, "A", Message{})
+	assert.Error(t, err)
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		value    int64
+		decimals int
+		want     string
+	}{
+		{1500000, 6, "1.5"},
+		{1000000, 6, "1"},
+		{1, 6, "0.000001"},
+		{0, 6, "0"},
+		{-2500, 3, "-2.5"},
+		{42, 0, "42"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatUnits(big.NewInt(tt.value), tt.decimals), "%d/%d", tt.value, tt.decimals)
+	}
+}
+
+func TestRenderDomain(t *testing.T) {
+	domain := createTestDomainWithContract("USD Coin", "2", 137, "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359")
+	want := "EIP712Domain\n" +
+		"  name: USD Coin\n" +
+		"  version: 2\n" +
+		"  chainId: Polygon (137)\n" +
+		"  verifyingContract: 0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359\n"
+	assert.Equal(t, want, RenderDomain(domain).Text())
+
+	unknown := RenderDomain(createTestDomain("App", "1", 999999)).Fields[2]
+	assert.Equal(t, "unknown chain 999999", unknown.Value)
+}
@@ -0,0 +1,462 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Well-known Seaport deployments
+var (
+	SeaportV15Address = common.HexToAddress("0x00000000000000ADc04C56Bf30aC9d3c0aAF14dC")
+	SeaportV16Address = common.HexToAddress("0x0000000000000068F116a894984e2DB1123eB395")
+)
+
+// SeaportMaxBulkOrderHeight is the tallest bulk order tree Seaport accepts
+const SeaportMaxBulkOrderHeight = 24
+
+// SeaportItemType identifies the asset class of an offer or consideration item
+type SeaportItemType uint8
+
+// Seaport item types
+const (
+	SeaportItemNative SeaportItemType = iota
+	SeaportItemERC20
+	SeaportItemERC721
+	SeaportItemERC1155
+	SeaportItemERC721WithCriteria
+	SeaportItemERC1155WithCriteria
+)
+
+// SeaportOrderType controls partial fills and zone restrictions of an order
+type SeaportOrderType uint8
+
+// Seaport order types
+const (
+	SeaportOrderFullOpen SeaportOrderType = iota
+	SeaportOrderPartialOpen
+	SeaportOrderFullRestricted
+	SeaportOrderPartialRestricted
+	SeaportOrderContract
+)
+
+// seaportOrderTypes is the EIP-712 schema of Seaport's OrderComponents
+var seaportOrderTypes = map[string][]Type{
+	"OrderComponents": {
+		{Name: "offerer", Type: "address"},
+		{Name: "zone", Type: "address"},
+		{Name: "offer", Type: "OfferItem[]"},
+		{Name: "consideration", Type: "ConsiderationItem[]"},
+		{Name: "orderType", Type: "uint8"},
+		{Name: "startTime", Type: "uint256"},
+		{Name: "endTime", Type: "uint256"},
+		{Name: "zoneHash", Type: "bytes32"},
+		{Name: "salt", Type: "uint256"},
+		{Name: "conduitKey", Type: "bytes32"},
+		{Name: "counter", Type: "uint256"},
+	},
+	"OfferItem": {
+		{Name: "itemType", Type: "uint8"},
+		{Name: "token", Type: "address"},
+		{Name: "identifierOrCriteria", Type: "uint256"},
+		{Name: "startAmount", Type: "uint256"},
+		{Name: "endAmount", Type: "uint256"},
+	},
+	"ConsiderationItem": {
+		{Name: "itemType", Type: "uint8"},
+		{Name: "token", Type: "address"},
+		{Name: "identifierOrCriteria", Type: "uint256"},
+		{Name: "startAmount", Type: "uint256"},
+		{Name: "endAmount", Type: "uint256"},
+		{Name: "recipient", Type: "address"},
+	},
+}
+
+// SeaportOfferItem is an item the offerer gives up
+type SeaportOfferItem struct {
+	ItemType             SeaportItemType
+	Token                common.Address
+	IdentifierOrCriteria *big.Int
+	StartAmount          *big.Int
+	EndAmount            *big.Int
+}
+
+// SeaportConsiderationItem is an item the offerer expects to receive
+type SeaportConsiderationItem struct {
+	ItemType             SeaportItemType
+	Token                common.Address
+	IdentifierOrCriteria *big.Int
+	StartAmount          *big.Int
+	EndAmount            *big.Int
+	Recipient            common.Address
+}
+
+// SeaportOrder mirrors Seaport's OrderComponents struct, the data an offerer signs
+type SeaportOrder struct {
+	Offerer       common.Address
+	Zone          common.Address
+	Offer         []SeaportOfferItem
+	Consideration []SeaportConsiderationItem
+	OrderType     SeaportOrderType
+	StartTime     *big.Int
+	EndTime       *big.Int
+	ZoneHash      [32]byte
+	Salt          *big.Int
+	ConduitKey    [32]byte
+	Counter       *big.Int
+}
+
+// SeaportDomain returns the EIP-712 domain of a Seaport deployment. The
+// version is "1.5" for SeaportV15Address and "1.6" for any other address.
+func SeaportDomain(chainID *big.Int, seaport common.Address) Domain {
+	return SeaportDomainVersion(chainID, seaport, seaportVersion(seaport))
+}
+
+// SeaportDomainVersion returns the EIP-712 domain of a Seaport deployment
+// of the given version, such as "1.5", for deployments at other addresses
+func SeaportDomainVersion(chainID *big.Int, seaport common.Address, version string) Domain {
+	return Domain{
+		Name:              "Seaport",
+		Version:           version,
+		ChainID:           chainID,
+		VerifyingContract: seaport,
+	}
+}
+
+func seaportVersion(seaport common.Address) string {
+	if seaport == SeaportV15Address {
+		return "1.5"
+	}
+	return "1.6"
+}
+
+// Message returns the order as an OrderComponents EIP-712 message
+func (o *SeaportOrder) Message() Message {
+	offer := make([]interface{}, len(o.Offer))
+	for i, item := range o.Offer {
+		offer[i] = map[string]interface{}{
+			"itemType":             fmt.Sprintf("%d", item.ItemType),
+			"token":                item.Token.Hex(),
+			"identifierOrCriteria": bigIntString(item.IdentifierOrCriteria),
+			"startAmount":          bigIntString(item.StartAmount),
+			"endAmount":            bigIntString(item.EndAmount),
+		}
+	}
+
+	consideration := make([]interface{}, len(o.Consideration))
+	for i, item := range o.Consideration {
+		consideration[i] = map[string]interface{}{
+			"itemType":             fmt.Sprintf("%d", item.ItemType),
+			"token":                item.Token.Hex(),
+			"identifierOrCriteria": bigIntString(item.IdentifierOrCriteria),
+			"startAmount":          bigIntString(item.StartAmount),
+			"endAmount":            bigIntString(item.EndAmount),
+			"recipient":            item.Recipient.Hex(),
+		}
+	}
+
+	return Message{
+		"offerer":       o.Offerer.Hex(),
+		"zone":          o.Zone.Hex(),
+		"offer":         offer,
+		"consideration": consideration,
+		"orderType":     fmt.Sprintf("%d", o.OrderType),
+		"startTime":     bigIntString(o.StartTime),
+		"endTime":       bigIntString(o.EndTime),
+		"zoneHash":      hexutil.Encode(o.ZoneHash[:]),
+		"salt":          bigIntString(o.Salt),
+		"conduitKey":    hexutil.Encode(o.ConduitKey[:]),
+		"counter":       bigIntString(o.Counter),
+	}
+}
+
+// Hash returns the order hash Seaport derives for the order (the EIP-712
+// struct hash of its OrderComponents)
+func (o *SeaportOrder) Hash() ([]byte, error) {
+	message := o.Message()
+	encoder := NewFastTypedDataEncoder(Domain{}, seaportOrderTypes, "OrderComponents", message)
+	return encoder.HashStruct("OrderComponents", message)
+}
+
+// SignSeaportOrder signs a single Seaport order for the signer's chain
+func (s *Signer) SignSeaportOrder(order *SeaportOrder, seaport common.Address) (*Signature, error) {
+	domain := SeaportDomain(s.chainID, seaport)
+	message := order.Message()
+	if err := s.checkPolicies(TypedData{Domain: domain, Types: seaportOrderTypes, PrimaryType: "OrderComponents", Message: message}); err != nil {
+		return nil, err
+	}
+
+	encoder := NewFastTypedDataEncoder(domain, seaportOrderTypes, "OrderComponents", message)
+	hash, err := encoder.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	return s.signDigest(context.Background(), TypedData{Domain: domain, Types: seaportOrderTypes, PrimaryType: "OrderComponents", Message: message}, hash)
+}
+
+// SeaportBulkOrder builds a Seaport bulk order: up to 2^24 orders placed in a
+// padded merkle tree and signed once as a BulkOrder struct. Each order is then
+// submitted with its own bulk signature, which appends the order's index and
+// merkle proof to the shared signature.
+//
+// Example:
+//
+//	bulk, err := NewSeaportBulkOrder(SeaportDomain(big.NewInt(1), SeaportV16Address), orders)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	signatures, err := signer.SignSeaportBulkOrder(bulk)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// signatures[i] is submitted alongside orders[i]
+type SeaportBulkOrder struct {
+	Domain Domain
+	Orders []*SeaportOrder
+
+	height int
+	// layers[0] holds the padded leaves, the last layer holds the root
+	layers [][][]byte
+}
+
+// NewSeaportBulkOrder hashes the orders and builds their merkle tree
+func NewSeaportBulkOrder(domain Domain, orders []*SeaportOrder) (*SeaportBulkOrder, error) {
+	if len(orders) == 0 {
+		return nil, errors.New("bulk order requires at least one order")
+	}
+	for i, order := range orders {
+		if order == nil {
+			return nil, fmt.Errorf("order %d is nil", i)
+		}
+	}
+
+	height := 1
+	for 1<<height < len(orders) {
+		height++
+	}
+	if height > SeaportMaxBulkOrderHeight {
+		return nil, fmt.Errorf("too many orders for a bulk order: %d", len(orders))
+	}
+
+	// Hash the orders, padding the tree with empty orders
+	leaves := make([][]byte, 1<<height)
+	for i, order := range orders {
+		hash, err := order.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash order %d: %w", i, err)
+		}
+		leaves[i] = hash
+	}
+	if len(orders) < len(leaves) {
+		empty, err := (&SeaportOrder{}).Hash()
+		if err != nil {
+			return nil, err
+		}
+		for i := len(orders); i < len(leaves); i++ {
+			leaves[i] = empty
+		}
+	}
+
+	layers := [][][]byte{leaves}
+	for layer := leaves; len(layer) > 1; {
+		next := make([][]byte, len(layer)/2)
+		for i := range next {
+			next[i] = crypto.Keccak256(layer[2*i], layer[2*i+1])
+		}
+		layers = append(layers, next)
+		layer = next
+	}
+
+	return &SeaportBulkOrder{
+		Domain: domain,
+		Orders: orders,
+		height: height,
+		layers: layers,
+	}, nil
+}
+
+// Height returns the height of the bulk order tree
+func (b *SeaportBulkOrder) Height() int {
+	return b.height
+}
+
+// Root returns the merkle root of the order hashes
+func (b *SeaportBulkOrder) Root() common.Hash {
+	return common.BytesToHash(b.layers[len(b.layers)-1][0])
+}
+
+// Types returns the EIP-712 schema of the bulk order, whose tree field is a
+// nested array of OrderComponents with one [2] dimension per tree level
+func (b *SeaportBulkOrder) Types() map[string][]Type {
+	return seaportBulkOrderTypes(b.height)
+}
+
+// Message returns the BulkOrder EIP-712 message, with the orders arranged in
+// a nested array padded with empty orders
+func (b *SeaportBulkOrder) Message() Message {
+	empty := (&SeaportOrder{}).Message()
+	nodes := make([]interface{}, 1<<b.height)
+	for i := range nodes {
+		if i < len(b.Orders) {
+			nodes[i] = map[string]interface{}(b.Orders[i].Message())
+		} else {
+			nodes[i] = map[string]interface{}(empty)
+		}
+	}
+
+	for len(nodes) > 1 {
+		next := make([]interface{}, len(nodes)/2)
+		for i := range next {
+			next[i] = []interface{}{nodes[2*i], nodes[2*i+1]}
+		}
+		nodes = next
+	}
+
+	return Message{"tree": nodes[0]}
+}
+
+// Hash returns the EIP-712 digest of the BulkOrder, the value the offerer signs
+func (b *SeaportBulkOrder) Hash() ([]byte, error) {
+	encoder := NewFastTypedDataEncoder(b.Domain, b.Types(), "BulkOrder", b.Message())
+	return encoder.Hash()
+}
+
+// Proof returns the merkle proof of the order at index, from leaf to root
+func (b *SeaportBulkOrder) Proof(index int) ([]common.Hash, error) {
+	if index < 0 || index >= len(b.Orders) {
+		return nil, fmt.Errorf("order index out of range: %d", index)
+	}
+
+	proof := make([]common.Hash, 0, b.height)
+	for _, layer := range b.layers[:len(b.layers)-1] {
+		proof = append(proof, common.BytesToHash(layer[index^1]))
+		index >>= 1
+	}
+	return proof, nil
+}
+
+// EncodeSignature appends the index and merkle proof of the order at index to
+// a signature over the bulk order, producing the bytes Seaport expects
+func (b *SeaportBulkOrder) EncodeSignature(sig *Signature, index int) (string, error) {
+	sigBytes, err := hexutil.Decode(sig.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature hex: %w", err)
+	}
+
+	proof, err := b.Proof(index)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := make([]byte, 0, len(sigBytes)+3+len(proof)*32)
+	encoded = append(encoded, sigBytes...)
+	encoded = append(encoded, byte(index>>16), byte(index>>8), byte(index))
+	for _, node := range proof {
+		encoded = append(encoded, node.Bytes()...)
+	}
+	return hexutil.Encode(encoded), nil
+}
+
+// SignSeaportBulkOrder signs the bulk order once and returns the bulk
+// signature of each order, in the same order as bulk.Orders
+func (s *Signer) SignSeaportBulkOrder(bulk *SeaportBulkOrder) ([]string, error) {
+	if bulk == nil {
+		return nil, errors.New("bulk order is nil")
+	}
+	for i, order := range bulk.Orders {
+		if order == nil {
+			return nil, fmt.Errorf("order %d is nil", i)
+		}
+		data := TypedData{Domain: bulk.Domain, Types: seaportOrderTypes, PrimaryType: "OrderComponents", Message: order.Message()}
+		if err := s.checkPolicies(data); err != nil {
+			return nil, fmt.Errorf("order %d: %w", i, err)
+		}
+	}
+
+	hash, err := bulk.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	data := TypedData{Domain: bulk.Domain, Types: bulk.Types(), PrimaryType: "BulkOrder", Message: bulk.Message()}
+	sig, err := s.signDigest(context.Background(), data, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make([]string, len(bulk.Orders))
+	for i := range bulk.Orders {
+		signatures[i], err = bulk.EncodeSignature(sig, i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signatures, nil
+}
+
+// RecoverSeaportBulkSignature recovers the offerer of order from a bulk
+// signature, the way Seaport validates it: the order hash and proof are folded
+// into the tree root, which is hashed as a BulkOrder under domain.
+func RecoverSeaportBulkSignature(domain Domain, order *SeaportOrder, bulkSignature string) (common.Address, error) {
+	encoded, err := hexutil.Decode(bulkSignature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature hex: %w", err)
+	}
+
+	if len(encoded) < 65+3+32 || (len(encoded)-65-3)%32 != 0 {
+		return common.Address{}, errors.New("invalid bulk signature length")
+	}
+	height := (len(encoded) - 65 - 3) / 32
+	if height > SeaportMaxBulkOrderHeight {
+		return common.Address{}, fmt.Errorf("bulk order tree too tall: %d", height)
+	}
+
+	index := int(encoded[65])<<16 | int(encoded[66])<<8 | int(encoded[67])
+	node, err := order.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	for i := 0; i < height; i++ {
+		sibling := encoded[68+i*32 : 68+(i+1)*32]
+		if index>>i&1 == 0 {
+			node = crypto.Keccak256(node, sibling)
+		} else {
+			node = crypto.Keccak256(sibling, node)
+		}
+	}
+
+	// BulkOrder's only member is the tree, whose encoding is the root
+	encoder := NewFastTypedDataEncoder(domain, seaportBulkOrderTypes(height), "BulkOrder", nil)
+	domainSeparator, err := encoder.DomainSeparator()
+	if err != nil {
+		return common.Address{}, err
+	}
+	typeHash, err := encoder.typeHash("BulkOrder")
+	if err != nil {
+		return common.Address{}, err
+	}
+	hash := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, crypto.Keccak256(typeHash, node))
+
+	return recoverHash(hash, hexutil.Encode(encoded[:65]))
+}
+
+// seaportBulkOrderTypes returns the Seaport schema extended with a BulkOrder
+// type for a tree of the given height
+func seaportBulkOrderTypes(height int) map[string][]Type {
+	types := make(map[string][]Type, len(seaportOrderTypes)+1)
+	for name, fields := range seaportOrderTypes {
+		types[name] = fields
+	}
+	types["BulkOrder"] = []Type{
+		{Name: "tree", Type: "OrderComponents" + strings.Repeat("[2]", height)},
+	}
+	return types
+}
@@ -0,0 +1,229 @@
+package eip712
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSeaportOrder(salt int64) *SeaportOrder {
+	return &SeaportOrder{
+		Offerer: common.HexToAddress(testAddress1),
+		Offer: []SeaportOfferItem{
+			{
+				ItemType:             SeaportItemERC721,
+				Token:                common.HexToAddress("0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"),
+				IdentifierOrCriteria: big.NewInt(1234),
+				StartAmount:          big.NewInt(1),
+				EndAmount:            big.NewInt(1),
+			},
+		},
+		Consideration: []SeaportConsiderationItem{
+			{
+				ItemType:             SeaportItemNative,
+				IdentifierOrCriteria: big.NewInt(0),
+				StartAmount:          big.NewInt(975000000000000000),
+				EndAmount:            big.NewInt(975000000000000000),
+				Recipient:            common.HexToAddress(testAddress1),
+			},
+			{
+				ItemType:             SeaportItemNative,
+				IdentifierOrCriteria: big.NewInt(0),
+				StartAmount:          big.NewInt(25000000000000000),
+				EndAmount:            big.NewInt(25000000000000000),
+				Recipient:            common.HexToAddress("0x0000a26b00c1F0DF003000390027140000fAa719"),
+			},
+		},
+		OrderType: SeaportOrderFullOpen,
+		StartTime: big.NewInt(1700000000),
+		EndTime:   big.NewInt(1800000000),
+		Salt:      big.NewInt(salt),
+		Counter:   big.NewInt(0),
+	}
+}
+
+func TestSeaportOrderHash(t *testing.T) {
+	encoder := NewFastTypedDataEncoder(Domain{}, seaportOrderTypes, "OrderComponents", nil)
+	typeHash, err := encoder.typeHash("OrderComponents")
+	require.NoError(t, err)
+	// Seaport's _ORDER_TYPEHASH
+	require.Equal(t, "0xfa445660b7e21515a59617fcd68910b487aa5808b8abda3d78bc85df364b2c2f", hexutil.Encode(typeHash))
+
+	hash1, err := createTestSeaportOrder(1).Hash()
+	require.NoError(t, err)
+	hash2, err := createTestSeaportOrder(2).Hash()
+	require.NoError(t, err)
+	require.Len(t, hash1, 32)
+	require.NotEqual(t, hash1, hash2)
+}
+
+func TestSignSeaportOrder(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	order := createTestSeaportOrder(1)
+	sig, err := signer.SignSeaportOrder(order, SeaportV16Address)
+	require.NoError(t, err)
+	assertSignatureComponents(t, sig)
+
+	domain := SeaportDomain(signer.ChainID(), SeaportV16Address)
+	recovered, err := RecoverSignatureFast(sig, domain, seaportOrderTypes, "OrderComponents", order.Message())
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), recovered)
+}
+
+func TestSeaportDomainVersion(t *testing.T) {
+	domainTypeHash := crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	expected := func(version string, seaport common.Address) []byte {
+		return crypto.Keccak256(
+			domainTypeHash,
+			crypto.Keccak256([]byte("Seaport")),
+			crypto.Keccak256([]byte(version)),
+			common.LeftPadBytes(big.NewInt(1).Bytes(), 32),
+			common.LeftPadBytes(seaport.Bytes(), 32),
+		)
+	}
+
+	for seaport, version := range map[common.Address]string{SeaportV15Address: "1.5", SeaportV16Address: "1.6"} {
+		domain := SeaportDomain(big.NewInt(1), seaport)
+		require.Equal(t, version, domain.Version)
+
+		separator, err := NewFastTypedDataEncoder(domain, seaportOrderTypes, "OrderComponents", nil).DomainSeparator()
+		require.NoError(t, err)
+		require.Equal(t, expected(version, seaport), separator)
+	}
+
+	// An order signed for 1.5 recovers only under the 1.5 domain
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	order := createTestSeaportOrder(1)
+	sig, err := signer.SignSeaportOrder(order, SeaportV15Address)
+	require.NoError(t, err)
+
+	recovered, err := RecoverSignatureFast(sig, SeaportDomainVersion(big.NewInt(1), SeaportV15Address, "1.5"), seaportOrderTypes, "OrderComponents", order.Message())
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), recovered)
+	recovered, err = RecoverSignatureFast(sig, SeaportDomainVersion(big.NewInt(1), SeaportV15Address, "1.6"), seaportOrderTypes, "OrderComponents", order.Message())
+	require.NoError(t, err)
+	require.NotEqual(t, signer.Address(), recovered)
+}
+
+func TestSeaportBulkOrder(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	domain := SeaportDomain(signer.ChainID(), SeaportV16Address)
+
+	testCases := []struct {
+		name       string
+		orders     int
+		wantHeight int
+	}{
+		{name: "Single order", orders: 1, wantHeight: 1},
+		{name: "Full tree", orders: 4, wantHeight: 2},
+		{name: "Padded tree", orders: 5, wantHeight: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orders := make([]*SeaportOrder, tc.orders)
+			for i := range orders {
+				orders[i] = createTestSeaportOrder(int64(i))
+			}
+
+			bulk, err := NewSeaportBulkOrder(domain, orders)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantHeight, bulk.Height())
+
+			// The nested-array encoding of the tree must equal the merkle root
+			encoder := NewFastTypedDataEncoder(domain, bulk.Types(), "BulkOrder", bulk.Message())
+			encodedType, err := encoder.encodeType("BulkOrder")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(encodedType, "BulkOrder(OrderComponents"+strings.Repeat("[2]", tc.wantHeight)+" tree)ConsiderationItem("))
+
+			domainSeparator, err := encoder.DomainSeparator()
+			require.NoError(t, err)
+			root := bulk.Root()
+			expected := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, crypto.Keccak256(crypto.Keccak256([]byte(encodedType)), root.Bytes()))
+
+			hash, err := bulk.Hash()
+			require.NoError(t, err)
+			require.Equal(t, expected, hash)
+
+			signatures, err := signer.SignSeaportBulkOrder(bulk)
+			require.NoError(t, err)
+			require.Len(t, signatures, tc.orders)
+
+			for i, bulkSig := range signatures {
+				sigBytes := hexutil.MustDecode(bulkSig)
+				require.Len(t, sigBytes, 65+3+32*tc.wantHeight)
+
+				recovered, err := RecoverSeaportBulkSignature(domain, orders[i], bulkSig)
+				require.NoError(t, err)
+				require.Equal(t, signer.Address(), recovered)
+			}
+
+			// A bulk signature must not validate a different order
+			recovered, err := RecoverSeaportBulkSignature(domain, createTestSeaportOrder(99), signatures[0])
+			require.NoError(t, err)
+			require.NotEqual(t, signer.Address(), recovered)
+		})
+	}
+
+	t.Run("No orders", func(t *testing.T) {
+		_, err := NewSeaportBulkOrder(domain, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("Nil inputs", func(t *testing.T) {
+		_, err := NewSeaportBulkOrder(domain, []*SeaportOrder{createTestSeaportOrder(0), nil})
+		require.EqualError(t, err, "order 1 is nil")
+
+		_, err = signer.SignSeaportBulkOrder(nil)
+		require.EqualError(t, err, "bulk order is nil")
+
+		bulk, err := NewSeaportBulkOrder(domain, []*SeaportOrder{createTestSeaportOrder(0)})
+		require.NoError(t, err)
+		bulk.Orders[0] = nil
+		_, err = signer.SignSeaportBulkOrder(bulk)
+		require.EqualError(t, err, "order 0 is nil")
+	})
+}
+
+func TestFastEncoderFixedSizeArrays(t *testing.T) {
+	domain := createTestDomain("Arrays", "1", 1)
+	types := map[string][]Type{
+		"Grid": {{Name: "cells", Type: "uint256[2][3]"}},
+	}
+
+	cells := []interface{}{
+		[]interface{}{"1", "2"},
+		[]interface{}{"3", "4"},
+		[]interface{}{"5", "6"},
+	}
+	encoder := NewFastTypedDataEncoder(domain, types, "Grid", Message{"cells": cells})
+	hash, err := encoder.HashStruct("Grid", Message{"cells": cells})
+	require.NoError(t, err)
+
+	word := func(n int64) []byte { return common.LeftPadBytes(big.NewInt(n).Bytes(), 32) }
+	inner := make([]byte, 0, 96)
+	for i := int64(0); i < 3; i++ {
+		inner = append(inner, crypto.Keccak256(word(2*i+1), word(2*i+2))...)
+	}
+	typeHash := crypto.Keccak256([]byte("Grid(uint256[2][3] cells)"))
+	require.Equal(t, crypto.Keccak256(typeHash, crypto.Keccak256(inner)), hash)
+
+	t.Run("Wrong length", func(t *testing.T) {
+		bad := []interface{}{
+			[]interface{}{"1", "2", "3"},
+			[]interface{}{"3", "4"},
+			[]interface{}{"5", "6"},
+		}
+		_, err := NewFastTypedDataEncoder(domain, types, "Grid", Message{"cells": bad}).Hash()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,415 @@
+package eip712
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// typedDataJSON is the eth_signTypedData_v4 document layout
+type typedDataJSON struct {
+	Types       map[string][]Type          `json:"types"`
+	PrimaryType string                     `json:"primaryType"`
+	Domain      map[string]json.RawMessage `json:"domain"`
+	Message     json.RawMessage            `json:"message"`
+}
+
+// domainFieldBits maps EIP712Domain field names to their bits and types
+var domainFieldBits = map[string]struct {
+	bit DomainFields
+	typ string
+}{
+	"name":              {DomainFieldName, "string"},
+	"version":           {DomainFieldVersion, "string"},
+	"chainId":           {DomainFieldChainID, "uint256"},
+	"verifyingContract": {DomainFieldVerifyingContract, "address"},
+	"salt":              {DomainFieldSalt, "bytes32"},
+}
+
+// MarshalJSON encodes the typed data as an eth_signTypedData_v4 document.
+// The types always include EIP712Domain, the domain holds exactly its
+// fields, and message values are encoded as wallets expect: integers as
+// decimal strings and bytes as hex.
+func (td TypedData) MarshalJSON() ([]byte, error) {
+	types := make(map[string][]Type, len(td.Types)+1)
+	for name, fields := range td.Types {
+		types[name] = fields
+	}
+	domainTypes, ok := types["EIP712Domain"]
+	if !ok {
+		domainTypes = td.Domain.FieldSet().Types()
+		types["EIP712Domain"] = domainTypes
+	}
+
+	domain, err := domainJSON(td.Domain, domainTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := json.Marshal(jsonSafeValue(map[string]interface{}(td.Message)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	return json.Marshal(typedDataJSON{
+		Types:       types,
+		PrimaryType: td.PrimaryType,
+		Domain:      domain,
+		Message:     message,
+	})
+}
+
+// MarshalJSON encodes the domain as in a typed data document, with exactly
+// the fields of FieldSet, so that the field selection survives decoding
+func (d Domain) MarshalJSON() ([]byte, error) {
+	values, err := domainJSON(d, d.FieldSet().Types())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON decodes a domain object as found in a typed data document.
+// The fields present become the domain's field selection.
+func (d *Domain) UnmarshalJSON(data []byte) error {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid domain: %w", err)
+	}
+	domain, err := parseDomainJSON(values, nil)
+	if err != nil {
+		return err
+	}
+	*d = domain
+	return nil
+}
+
+// domainJSON encodes the values of the named domain fields
+func domainJSON(d Domain, domainTypes []Type) (map[string]json.RawMessage, error) {
+	values := make(map[string]json.RawMessage, len(domainTypes))
+	for _, field := range domainTypes {
+		var value interface{}
+		switch field.Name {
+		case "name":
+			value = d.Name
+		case "version":
+			value = d.Version
+		case "chainId":
+			value = json.Number(domainChainID(d).String())
+		case "verifyingContract":
+			value = d.VerifyingContract.Hex()
+		case "salt":
+			value = hexutil.Encode(d.Salt[:])
+		default:
+			return nil, fmt.Errorf("unsupported domain field: %s", field.Name)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		values[field.Name] = encoded
+	}
+	return values, nil
+}
+
+// UnmarshalJSON decodes an eth_signTypedData_v4 document, as sent by
+// wallets and dapps. Numbers may be JSON numbers or hex or decimal strings;
+// domain fields are optional, and an EIP712Domain type, if given, selects
+// them. Message values are checked against the schema and converted to
+// canonical form: integers to *big.Int, addresses to checksummed hex and
+// bytes to lowercase hex. A message field the schema does not declare is an
+// error, since it would not be signed.
+//
+// The result marshals back to an equivalent document, and decoding that
+// document yields an identical TypedData.
+func (td *TypedData) UnmarshalJSON(data []byte) error {
+	var doc typedDataJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid typed data: %w", err)
+	}
+	if doc.PrimaryType == "" {
+		return fmt.Errorf("invalid typed data: missing primaryType")
+	}
+	if _, ok := doc.Types[doc.PrimaryType]; !ok {
+		return fmt.Errorf("invalid typed data: primary type %s is not defined", doc.PrimaryType)
+	}
+	if err := validateNoCycles(doc.Types); err != nil {
+		return fmt.Errorf("invalid typed data: %w", err)
+	}
+
+	domain, err := parseDomainJSON(doc.Domain, doc.Types["EIP712Domain"])
+	if err != nil {
+		return fmt.Errorf("invalid typed data: %w", err)
+	}
+
+	// An EIP712Domain type in standard order is implied by Domain.Fields;
+	// only a reordered one has to be kept
+	types := doc.Types
+	if domainTypes, ok := types["EIP712Domain"]; ok && reflect.DeepEqual(domainTypes, domain.FieldSet().Types()) {
+		types = make(map[string][]Type, len(doc.Types)-1)
+		for name, fields := range doc.Types {
+			if name != "EIP712Domain" {
+				types[name] = fields
+			}
+		}
+	}
+
+	var raw interface{}
+	decoder := json.NewDecoder(bytes.NewReader(doc.Message))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid typed data: message: %w", err)
+	}
+	if raw == nil {
+		return fmt.Errorf("invalid typed data: missing message")
+	}
+	message, err := canonicalValue(types, doc.PrimaryType, "message", raw)
+	if err != nil {
+		return fmt.Errorf("invalid typed data: %w", err)
+	}
+
+	*td = TypedData{
+		Domain:      domain,
+		Types:       types,
+		PrimaryType: doc.PrimaryType,
+		Message:     Message(message.(map[string]interface{})),
+	}
+	return nil
+}
+
+// parseDomainJSON decodes a domain object. domainTypes, if not nil, is the
+// document's EIP712Domain type; otherwise the fields present are used.
+// Values that are not hashed, because the type omits them or they are not
+// EIP712Domain fields, are ignored as wallets ignore them.
+func parseDomainJSON(values map[string]json.RawMessage, domainTypes []Type) (Domain, error) {
+	var domain Domain
+	var fields DomainFields
+
+	if domainTypes != nil {
+		for _, field := range domainTypes {
+			known, ok := domainFieldBits[field.Name]
+			if !ok || known.typ != field.Type {
+				return Domain{}, fmt.Errorf("unsupported EIP712Domain field: %s %s", field.Type, field.Name)
+			}
+			if _, ok := values[field.Name]; !ok {
+				return Domain{}, fmt.Errorf("domain: missing field %s", field.Name)
+			}
+			fields |= known.bit
+		}
+	} else {
+		for name := range values {
+			if known, ok := domainFieldBits[name]; ok {
+				fields |= known.bit
+			}
+		}
+	}
+
+	for name, value := range values {
+		if known, ok := domainFieldBits[name]; !ok || !fields.Has(known.bit) {
+			continue
+		}
+		var err error
+		switch name {
+		case "name":
+			err = json.Unmarshal(value, &domain.Name)
+		case "version":
+			err = json.Unmarshal(value, &domain.Version)
+		case "chainId":
+			domain.ChainID, err = parseJSONInteger(value)
+			if err == nil && domain.ChainID.Sign() < 0 {
+				err = fmt.Errorf("negative chain ID")
+			}
+		case "verifyingContract":
+			var address string
+			if err = json.Unmarshal(value, &address); err == nil {
+				if !common.IsHexAddress(address) {
+					err = fmt.Errorf("invalid address: %s", address)
+				}
+				domain.VerifyingContract = common.HexToAddress(address)
+			}
+		case "salt":
+			var salt string
+			if err = json.Unmarshal(value, &salt); err == nil {
+				var b []byte
+				if b, err = hexutil.Decode(salt); err == nil && len(b) != 32 {
+					err = fmt.Errorf("salt must be 32 bytes, got %d", len(b))
+				}
+				copy(domain.Salt[:], b)
+			}
+		}
+		if err != nil {
+			return Domain{}, fmt.Errorf("domain.%s: %w", name, err)
+		}
+	}
+
+	// Fields stays zero when the default selection already matches
+	if domain.FieldSet() != fields {
+		domain.Fields = fields
+	}
+	return domain, nil
+}
+
+// parseJSONInteger decodes an integer given as a JSON number or as a hex
+// or decimal string
+func parseJSONInteger(data json.RawMessage) (*big.Int, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return canonicalInteger(value)
+}
+
+func canonicalInteger(value interface{}) (*big.Int, error) {
+	n, err := decodeInteger(value)
+	if err != nil || n.Sign() != 0 {
+		return n, err
+	}
+	// One representation of zero, so decoded documents compare equal
+	return new(big.Int), nil
+}
+
+func decodeInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case json.Number:
+		n, ok := new(big.Int).SetString(string(v), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %s", v)
+		}
+		return n, nil
+	case string:
+		if strings.HasPrefix(v, "-0x") {
+			n, err := toBigInt(v[1:])
+			if err != nil {
+				return nil, err
+			}
+			return n.Neg(n), nil
+		}
+		return toBigInt(v)
+	default:
+		return nil, fmt.Errorf("invalid integer type: %T", value)
+	}
+}
+
+// canonicalValue checks a decoded JSON value against fieldType and returns
+// it in canonical form
+func canonicalValue(types map[string][]Type, fieldType, path string, value interface{}) (interface{}, error) {
+	if elementType, length, ok, err := splitArrayType(fieldType); err != nil {
+		return nil, err
+	} else if ok {
+		items, isArray := value.([]interface{})
+		if !isArray {
+			return nil, fmt.Errorf("%s: expected array for type %s", path, fieldType)
+		}
+		if length > 0 && len(items) != length {
+			return nil, fmt.Errorf("%s: expected %d elements for type %s, got %d", path, length, fieldType, len(items))
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			if out[i], err = canonicalValue(types, elementType, fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	if fields, ok := types[fieldType]; ok {
+		data, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, fmt.Errorf("%s: expected object for type %s", path, fieldType)
+		}
+		out := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			item, ok := data[field.Name]
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: missing field", path, field.Name)
+			}
+			converted, err := canonicalValue(types, field.Type, path+"."+field.Name, item)
+			if err != nil {
+				return nil, err
+			}
+			out[field.Name] = converted
+		}
+		if len(data) != len(out) {
+			for name := range data {
+				if _, ok := out[name]; !ok {
+					return nil, fmt.Errorf("%s.%s: field is not in type %s", path, name, fieldType)
+				}
+			}
+		}
+		return out, nil
+	}
+
+	var converted interface{}
+	var err error
+	switch {
+	case fieldType == "string":
+		if _, ok := value.(string); !ok {
+			err = fmt.Errorf("expected string, got %T", value)
+		}
+		converted = value
+	case fieldType == "bool":
+		if _, ok := value.(bool); !ok {
+			err = fmt.Errorf("expected bool, got %T", value)
+		}
+		converted = value
+	case fieldType == "address":
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			err = fmt.Errorf("invalid address: %v", value)
+		} else {
+			converted = common.HexToAddress(s).Hex()
+		}
+	case strings.HasPrefix(fieldType, "bytes"):
+		s, ok := value.(string)
+		if !ok {
+			err = fmt.Errorf("expected hex string, got %T", value)
+		} else if b, decodeErr := hexutil.Decode(s); decodeErr != nil {
+			err = decodeErr
+		} else if size, sizeErr := fixedBytesSize(fieldType); sizeErr != nil {
+			err = sizeErr
+		} else if size > 0 && len(b) != size {
+			err = fmt.Errorf("expected %d bytes for %s, got %d", size, fieldType, len(b))
+		} else {
+			converted = hexutil.Encode(b)
+		}
+	case strings.HasPrefix(fieldType, "uint") || strings.HasPrefix(fieldType, "int"):
+		converted, err = canonicalInteger(value)
+	default:
+		err = fmt.Errorf("unsupported type: %s", fieldType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return converted, nil
+}
+
+// fixedBytesSize returns N for a bytesN type, or zero for dynamic bytes
+func fixedBytesSize(fieldType string) (int, error) {
+	if fieldType == "bytes" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(strings.TrimPrefix(fieldType, "bytes"))
+	if err != nil || size < 1 || size > 32 {
+		return 0, fmt.Errorf("unsupported type: %s", fieldType)
+	}
+	return size, nil
+}
+
+// copyTypes returns a deep copy of types, so callers handed a schema cannot
+// change the one the package hashes with
+func copyTypes(types map[string][]Type) map[string][]Type {
+	copied := make(map[string][]Type, len(types))
+	for name, fields := range types {
+		copied[name] = append([]Type(nil), fields...)
+	}
+	return copied
+}
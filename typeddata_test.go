@@ -0,0 +1,253 @@
+package eip712
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walletPermit is a permit as a dapp passes it to eth_signTypedData_v4
+const walletPermit = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Permit": [
+      {"name": "owner", "type": "address"},
+      {"name": "spender", "type": "address"},
+      {"name": "value", "type": "uint256"},
+      {"name": "nonce", "type": "uint256"},
+      {"name": "deadline", "type": "uint256"}
+    ]
+  },
+  "primaryType": "Permit",
+  "domain": {
+    "name": "USD Coin",
+    "version": "2",
+    "chainId": "0x1",
+    "verifyingContract": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+  },
+  "message": {
+    "owner": "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266",
+    "spender": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+    "value": 1000000,
+    "nonce": "0x0",
+    "deadline": "1893456000"
+  }
+}`
+
+func TestTypedDataUnmarshal(t *testing.T) {
+	var td TypedData
+	require.NoError(t, json.Unmarshal([]byte(walletPermit), &td))
+
+	assert.Equal(t, createTestDomainWithContract("USD Coin", "2", 1, testUSDC.Hex()), td.Domain)
+	assert.Equal(t, createPermitTypes(), td.Types, "standard EIP712Domain is implied by the domain")
+	assert.Equal(t, "Permit", td.PrimaryType)
+	assert.Equal(t, Message{
+		"owner":    common.HexToAddress(testAddress1).Hex(),
+		"spender":  common.HexToAddress(testAddress2).Hex(),
+		"value":    big.NewInt(1000000),
+		"nonce":    big.NewInt(0),
+		"deadline": big.NewInt(1893456000),
+	}, td.Message)
+
+	// Hashes as go-ethereum does from the same document
+	want := createTestPermit(testOwner, testSpender, big.NewInt(1000000), big.NewInt(0))
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	got, err := signer.SignTypedData(td.Domain, td.Types, td.PrimaryType, td.Message)
+	require.NoError(t, err)
+	expected, err := signer.SignTypedData(want.Domain, want.Types, want.PrimaryType, want.Message)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Hash, got.Hash)
+}
+
+func TestTypedDataRoundTrip(t *testing.T) {
+	documents := []string{walletPermit}
+
+	data, err := os.ReadFile("testdata/vectors.json")
+	require.NoError(t, err)
+	var vectors struct {
+		Vectors []json.RawMessage `json:"vectors"`
+	}
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	for _, vector := range vectors.Vectors {
+		documents = append(documents, string(vector))
+	}
+
+	for _, document := range documents {
+		var first TypedData
+		require.NoError(t, json.Unmarshal([]byte(document), &first))
+
+		encoded, err := json.Marshal(first)
+		require.NoError(t, err)
+		var second TypedData
+		require.NoError(t, json.Unmarshal(encoded, &second))
+		assert.Equal(t, first, second)
+
+		reencoded, err := json.Marshal(second)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(encoded), string(reencoded))
+
+		hash1, err := NewFastTypedDataEncoder(first.Domain, first.Types, first.PrimaryType, first.Message).Hash()
+		require.NoError(t, err)
+		hash2, err := NewFastTypedDataEncoder(second.Domain, second.Types, second.PrimaryType, second.Message).Hash()
+		require.NoError(t, err)
+		assert.Equal(t, hash1, hash2)
+	}
+}
+
+func TestTypedDataMarshal(t *testing.T) {
+	encoded, err := json.Marshal(createTestPermit(testOwner, testSpender, big.NewInt(1500000), big.NewInt(0)))
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &doc))
+	assert.Equal(t, map[string]interface{}{
+		"name":              "USD Coin",
+		"version":           "2",
+		"chainId":           float64(1),
+		"verifyingContract": testUSDC.Hex(),
+	}, doc["domain"])
+	assert.Len(t, doc["types"].(map[string]interface{})["EIP712Domain"], 4)
+	assert.Equal(t, "1500000", doc["message"].(map[string]interface{})["value"])
+}
+
+func TestTypedDataDomainFields(t *testing.T) {
+	t.Run("zero chain ID kept", func(t *testing.T) {
+		var td TypedData
+		require.NoError(t, json.Unmarshal([]byte(`{
+		  "types": {"M": [{"name": "a", "type": "bool"}]},
+		  "primaryType": "M",
+		  "domain": {"name": "App", "chainId": 0},
+		  "message": {"a": true}
+		}`), &td))
+		assert.Equal(t, DomainFieldName|DomainFieldChainID, td.Domain.FieldSet())
+		assert.Equal(t, 0, td.Domain.ChainID.Sign())
+
+		encoded, err := json.Marshal(td)
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"domain":{"chainId":0,"name":"App"}`)
+	})
+
+	t.Run("reordered EIP712Domain kept", func(t *testing.T) {
+		var td TypedData
+		require.NoError(t, json.Unmarshal([]byte(`{
+		  "types": {
+		    "EIP712Domain": [{"name": "version", "type": "string"}, {"name": "name", "type": "string"}],
+		    "M": [{"name": "a", "type": "bool"}]
+		  },
+		  "primaryType": "M",
+		  "domain": {"name": "App", "version": "1"},
+		  "message": {"a": true}
+		}`), &td))
+		assert.Len(t, td.Types["EIP712Domain"], 2)
+	})
+
+	t.Run("unhashed values ignored", func(t *testing.T) {
+		var td TypedData
+		require.NoError(t, json.Unmarshal([]byte(`{
+		  "types": {"EIP712Domain": [{"name": "name", "type": "string"}], "M": []},
+		  "primaryType": "M",
+		  "domain": {"name": "App", "version": "1", "chainId": "not a number", "owner": "me"},
+		  "message": {}
+		}`), &td))
+		assert.Equal(t, Domain{Name: "App", Fields: DomainFieldName}, td.Domain)
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+		  "types": {"M": []}, "primaryType": "M", "domain": {"name": "App", "owner": "me"}, "message": {}
+		}`), &td))
+		assert.Equal(t, Domain{Name: "App", Fields: DomainFieldName}, td.Domain)
+	})
+
+	t.Run("salt", func(t *testing.T) {
+		var td TypedData
+		require.NoError(t, json.Unmarshal([]byte(`{
+		  "types": {"M": []},
+		  "primaryType": "M",
+		  "domain": {"salt": "0x0100000000000000000000000000000000000000000000000000000000000000"},
+		  "message": {}
+		}`), &td))
+		assert.Equal(t, [32]byte{1}, td.Domain.Salt)
+		assert.Equal(t, DomainFieldSalt, td.Domain.FieldSet())
+	})
+}
+
+func TestTypedDataUnmarshalErrors(t *testing.T) {
+	mail := `"types": {"Mail": [{"name": "to", "type": "address"}, {"name": "amounts", "type": "uint8[2]"}]}, "primaryType": "Mail"`
+	tests := map[string]string{
+		"undefined primary type": `{"types": {}, "primaryType": "Mail", "domain": {}, "message": {}}`,
+		"missing field":          `{` + mail + `, "domain": {}, "message": {"to": "` + testAddress1 + `"}}`,
+		"undeclared field":       `{` + mail + `, "domain": {}, "message": {"to": "` + testAddress1 + `", "amounts": [1, 2], "extra": 1}}`,
+		"bad address":            `{` + mail + `, "domain": {}, "message": {"to": "0x1234", "amounts": [1, 2]}}`,
+		"fractional integer":     `{` + mail + `, "domain": {}, "message": {"to": "` + testAddress1 + `", "amounts": [1.5, 2]}}`,
+		"array length":           `{` + mail + `, "domain": {}, "message": {"to": "` + testAddress1 + `", "amounts": [1]}}`,
+		"short salt":             `{` + mail + `, "domain": {"salt": "0x01"}, "message": {"to": "` + testAddress1 + `", "amounts": [1, 2]}}`,
+		"negative chain ID":      `{` + mail + `, "domain": {"chainId": -1}, "message": {"to": "` + testAddress1 + `", "amounts": [1, 2]}}`,
+	}
+	for name, document := range tests {
+		t.Run(name, func(t *testing.T) {
+			var td TypedData
+			assert.Error(t, json.Unmarshal([]byte(document), &td))
+		})
+	}
+}
+
+func TestTypedDataUnmarshalFixedBytesLength(t *testing.T) {
+	schema := `"types": {"Order": [{"name": "hash", "type": "bytes32"}, {"name": "tag", "type": "bytes4"}]}, "primaryType": "Order", "domain": {}`
+	tests := map[string]string{
+		"short":  `{` + schema + `, "message": {"hash": "0x01", "tag": "0x01020304"}}`,
+		"long":   `{` + schema + `, "message": {"hash": "0x` + strings.Repeat("00", 33) + `", "tag": "0x01020304"}}`,
+		"bytes4": `{` + schema + `, "message": {"hash": "0x` + strings.Repeat("00", 32) + `", "tag": "0x0102"}}`,
+	}
+	fields := map[string]string{"short": "hash", "long": "hash", "bytes4": "tag"}
+	for name, document := range tests {
+		t.Run(name, func(t *testing.T) {
+			var td TypedData
+			err := json.Unmarshal([]byte(document), &td)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), fields[name])
+			assert.Contains(t, err.Error(), "expected")
+		})
+	}
+
+	var td TypedData
+	valid := `{` + schema + `, "message": {"hash": "0x` + strings.Repeat("00", 32) + `", "tag": "0x01020304"}}`
+	require.NoError(t, json.Unmarshal([]byte(valid), &td))
+}
+
+func TestDomainJSON(t *testing.T) {
+	domains := []Domain{
+		createTestDomainWithContract("USD Coin", "2", 1, testUSDC.Hex()),
+		{Name: "Salted", Version: "1", Salt: [32]byte{1, 2, 3}},
+		{Name: "Zero chain", ChainID: big.NewInt(0), Fields: DomainFieldName | DomainFieldChainID},
+	}
+	for _, domain := range domains {
+		encoded, err := json.Marshal(domain)
+		require.NoError(t, err)
+		var decoded Domain
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, domain.FieldSet(), decoded.FieldSet())
+		assert.Equal(t, domain.Name, decoded.Name)
+		assert.Equal(t, domain.Salt, decoded.Salt)
+		assert.Equal(t, domain.VerifyingContract, decoded.VerifyingContract)
+		assert.Equal(t, domainChainID(domain).String(), domainChainID(decoded).String())
+	}
+
+	encoded, err := json.Marshal(domains[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Salted", "version": "1", "salt": "0x0102030000000000000000000000000000000000000000000000000000000000"}`, string(encoded))
+
+	var domain Domain
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "App", "chainId": "0x89"}`), &domain))
+	assert.Equal(t, Domain{Name: "App", ChainID: big.NewInt(137), Fields: DomainFieldName | DomainFieldChainID}, domain)
+}
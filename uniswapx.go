@@ -0,0 +1,176 @@
+package eip712
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Permit2Address is the Permit2 contract, deployed at the same address on
+// every supported chain
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+// uniswapXExclusiveDutchOrderTypes is the Permit2 PermitWitnessTransferFrom
+// schema with an ExclusiveDutchOrder witness
+var uniswapXExclusiveDutchOrderTypes = map[string][]Type{
+	"PermitWitnessTransferFrom": {
+		{Name: "permitted", Type: "TokenPermissions"},
+		{Name: "spender", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+		{Name: "witness", Type: "ExclusiveDutchOrder"},
+	},
+	"TokenPermissions": {
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+	},
+	"ExclusiveDutchOrder": {
+		{Name: "info", Type: "OrderInfo"},
+		{Name: "decayStartTime", Type: "uint256"},
+		{Name: "decayEndTime", Type: "uint256"},
+		{Name: "exclusiveFiller", Type: "address"},
+		{Name: "exclusivityOverrideBps", Type: "uint256"},
+		{Name: "inputToken", Type: "address"},
+		{Name: "inputStartAmount", Type: "uint256"},
+		{Name: "inputEndAmount", Type: "uint256"},
+		{Name: "outputs", Type: "DutchOutput[]"},
+	},
+	"OrderInfo": {
+		{Name: "reactor", Type: "address"},
+		{Name: "swapper", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+		{Name: "additionalValidationContract", Type: "address"},
+		{Name: "additionalValidationData", Type: "bytes"},
+	},
+	"DutchOutput": {
+		{Name: "token", Type: "address"},
+		{Name: "startAmount", Type: "uint256"},
+		{Name: "endAmount", Type: "uint256"},
+		{Name: "recipient", Type: "address"},
+	},
+}
+
+// UniswapXOrderInfo holds the fields common to all UniswapX orders
+type UniswapXOrderInfo struct {
+	Reactor                      common.Address
+	Swapper                      common.Address
+	Nonce                        *big.Int
+	Deadline                     *big.Int
+	AdditionalValidationContract common.Address
+	AdditionalValidationData     []byte
+}
+
+// UniswapXDutchOutput is an output token whose amount decays over the auction
+type UniswapXDutchOutput struct {
+	Token       common.Address
+	StartAmount *big.Int
+	EndAmount   *big.Int
+	Recipient   common.Address
+}
+
+// UniswapXExclusiveDutchOrder is a UniswapX ExclusiveDutchOrder. The swapper
+// signs it as the witness of a Permit2 PermitWitnessTransferFrom, which lets
+// the reactor pull up to InputEndAmount of InputToken.
+type UniswapXExclusiveDutchOrder struct {
+	Info                   UniswapXOrderInfo
+	DecayStartTime         *big.Int
+	DecayEndTime           *big.Int
+	ExclusiveFiller        common.Address
+	ExclusivityOverrideBps *big.Int
+	InputToken             common.Address
+	InputStartAmount       *big.Int
+	InputEndAmount         *big.Int
+	Outputs                []UniswapXDutchOutput
+}
+
+// Permit2Domain returns the EIP-712 domain of Permit2 on the given chain,
+// which has no version field
+func Permit2Domain(chainID *big.Int) Domain {
+	return Domain{
+		Name:              "Permit2",
+		ChainID:           chainID,
+		VerifyingContract: Permit2Address,
+		Fields:            DomainFieldName | DomainFieldChainID | DomainFieldVerifyingContract,
+	}
+}
+
+// witnessMessage returns the order as an ExclusiveDutchOrder EIP-712 message
+func (o *UniswapXExclusiveDutchOrder) witnessMessage() map[string]interface{} {
+	outputs := make([]interface{}, len(o.Outputs))
+	for i, output := range o.Outputs {
+		outputs[i] = map[string]interface{}{
+			"token":       output.Token.Hex(),
+			"startAmount": bigIntString(output.StartAmount),
+			"endAmount":   bigIntString(output.EndAmount),
+			"recipient":   output.Recipient.Hex(),
+		}
+	}
+
+	return map[string]interface{}{
+		"info": map[string]interface{}{
+			"reactor":                      o.Info.Reactor.Hex(),
+			"swapper":                      o.Info.Swapper.Hex(),
+			"nonce":                        bigIntString(o.Info.Nonce),
+			"deadline":                     bigIntString(o.Info.Deadline),
+			"additionalValidationContract": o.Info.AdditionalValidationContract.Hex(),
+			"additionalValidationData":     hexutil.Encode(o.Info.AdditionalValidationData),
+		},
+		"decayStartTime":         bigIntString(o.DecayStartTime),
+		"decayEndTime":           bigIntString(o.DecayEndTime),
+		"exclusiveFiller":        o.ExclusiveFiller.Hex(),
+		"exclusivityOverrideBps": bigIntString(o.ExclusivityOverrideBps),
+		"inputToken":             o.InputToken.Hex(),
+		"inputStartAmount":       bigIntString(o.InputStartAmount),
+		"inputEndAmount":         bigIntString(o.InputEndAmount),
+		"outputs":                outputs,
+	}
+}
+
+// Types returns a copy of the PermitWitnessTransferFrom schema the order is
+// signed with
+func (o *UniswapXExclusiveDutchOrder) Types() map[string][]Type {
+	return copyTypes(uniswapXExclusiveDutchOrderTypes)
+}
+
+// Message returns the PermitWitnessTransferFrom message for the order: the
+// reactor may transfer up to InputEndAmount of InputToken, with the order as
+// witness
+func (o *UniswapXExclusiveDutchOrder) Message() Message {
+	return Message{
+		"permitted": map[string]interface{}{
+			"token":  o.InputToken.Hex(),
+			"amount": bigIntString(o.InputEndAmount),
+		},
+		"spender":  o.Info.Reactor.Hex(),
+		"nonce":    bigIntString(o.Info.Nonce),
+		"deadline": bigIntString(o.Info.Deadline),
+		"witness":  o.witnessMessage(),
+	}
+}
+
+// WitnessHash returns the EIP-712 struct hash of the order, the witness value
+// the reactor passes to Permit2
+func (o *UniswapXExclusiveDutchOrder) WitnessHash() ([]byte, error) {
+	witness := o.witnessMessage()
+	encoder := NewFastTypedDataEncoder(Domain{}, uniswapXExclusiveDutchOrderTypes, "ExclusiveDutchOrder", witness)
+	return encoder.HashStruct("ExclusiveDutchOrder", witness)
+}
+
+// Hash returns the Permit2 digest the swapper signs on the given chain
+func (o *UniswapXExclusiveDutchOrder) Hash(chainID *big.Int) ([]byte, error) {
+	encoder := NewFastTypedDataEncoder(Permit2Domain(chainID), uniswapXExclusiveDutchOrderTypes, "PermitWitnessTransferFrom", o.Message())
+	return encoder.Hash()
+}
+
+// SignUniswapXOrder signs an ExclusiveDutchOrder through Permit2 for the
+// signer's chain. The signer should be the order's swapper.
+func (s *Signer) SignUniswapXOrder(order *UniswapXExclusiveDutchOrder) (*Signature, error) {
+	return s.SignTypedData(Permit2Domain(s.chainID), uniswapXExclusiveDutchOrderTypes, "PermitWitnessTransferFrom", order.Message())
+}
+
+// VerifyUniswapXOrder verifies that the order's swapper signed it on the given chain
+func VerifyUniswapXOrder(sig *Signature, chainID *big.Int, order *UniswapXExclusiveDutchOrder) (bool, error) {
+	return VerifySignature(sig, order.Info.Swapper, Permit2Domain(chainID), uniswapXExclusiveDutchOrderTypes, "PermitWitnessTransferFrom", order.Message())
+}
@@ -0,0 +1,131 @@
+package eip712
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestUniswapXOrder() *UniswapXExclusiveDutchOrder {
+	return &UniswapXExclusiveDutchOrder{
+		Info: UniswapXOrderInfo{
+			Reactor:  common.HexToAddress("0x6000da47483062A0D734Ba3dc7576Ce6A0B645C4"),
+			Swapper:  common.HexToAddress(testAddress1),
+			Nonce:    big.NewInt(1993353164669688581),
+			Deadline: big.NewInt(1893456000),
+		},
+		DecayStartTime:         big.NewInt(1893455000),
+		DecayEndTime:           big.NewInt(1893455600),
+		ExclusiveFiller:        common.HexToAddress(testAddress2),
+		ExclusivityOverrideBps: big.NewInt(100),
+		InputToken:             common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		InputStartAmount:       big.NewInt(1000000000),
+		InputEndAmount:         big.NewInt(1000000000),
+		Outputs: []UniswapXDutchOutput{
+			{
+				Token:       common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+				StartAmount: big.NewInt(330000000000000000),
+				EndAmount:   big.NewInt(320000000000000000),
+				Recipient:   common.HexToAddress(testAddress1),
+			},
+		},
+	}
+}
+
+func TestUniswapXTypeHashes(t *testing.T) {
+	// Permit2 builds the witness type string by appending the witness type
+	// string provided by the reactor to its fixed prefix
+	const orderType = "ExclusiveDutchOrder(OrderInfo info,uint256 decayStartTime,uint256 decayEndTime,address exclusiveFiller,uint256 exclusivityOverrideBps,address inputToken,uint256 inputStartAmount,uint256 inputEndAmount,DutchOutput[] outputs)"
+	const dutchOutputType = "DutchOutput(address token,uint256 startAmount,uint256 endAmount,address recipient)"
+	const orderInfoType = "OrderInfo(address reactor,address swapper,uint256 nonce,uint256 deadline,address additionalValidationContract,bytes additionalValidationData)"
+	const tokenPermissionsType = "TokenPermissions(address token,uint256 amount)"
+	permitWitnessType := "PermitWitnessTransferFrom(TokenPermissions permitted,address spender,uint256 nonce,uint256 deadline," +
+		"ExclusiveDutchOrder witness)" + dutchOutputType + orderType + orderInfoType + tokenPermissionsType
+
+	encoder := NewFastTypedDataEncoder(Permit2Domain(big.NewInt(1)), uniswapXExclusiveDutchOrderTypes, "PermitWitnessTransferFrom", nil)
+	typeHash, err := encoder.typeHash("PermitWitnessTransferFrom")
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256([]byte(permitWitnessType)), typeHash)
+
+	typeHash, err = encoder.typeHash("ExclusiveDutchOrder")
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256([]byte(orderType+dutchOutputType+orderInfoType)), typeHash)
+}
+
+func TestUniswapXWitnessHash(t *testing.T) {
+	order := createTestUniswapXOrder()
+
+	witness, err := order.WitnessHash()
+	require.NoError(t, err)
+	require.Len(t, witness, 32)
+
+	// Changing an output changes the witness
+	other := createTestUniswapXOrder()
+	other.Outputs[0].EndAmount = big.NewInt(310000000000000000)
+	otherWitness, err := other.WitnessHash()
+	require.NoError(t, err)
+	require.NotEqual(t, witness, otherWitness)
+}
+
+func TestUniswapXTypesCopy(t *testing.T) {
+	order := createTestUniswapXOrder()
+	hash, err := order.Hash(big.NewInt(1))
+	require.NoError(t, err)
+
+	// Changing the returned schema leaves the order's hash alone
+	types := order.Types()
+	types["TokenPermissions"][0].Type = "uint256"
+	types["ExclusiveDutchOrder"] = nil
+	again, err := order.Hash(big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, hash, again)
+	require.Equal(t, uniswapXExclusiveDutchOrderTypes, createTestUniswapXOrder().Types())
+}
+
+func TestSignUniswapXOrder(t *testing.T) {
+	order := createTestUniswapXOrder()
+
+	for _, chainID := range []int64{1, 42161} {
+		signer, err := NewSigner(testPrivateKey1, chainID)
+		require.NoError(t, err)
+
+		sig, err := signer.SignUniswapXOrder(order)
+		require.NoError(t, err)
+		assertSignatureComponents(t, sig)
+
+		// Permit2's domain has no version field
+		domainSeparator, err := NewFastTypedDataEncoder(Permit2Domain(signer.ChainID()), order.Types(), "PermitWitnessTransferFrom", order.Message()).DomainSeparator()
+		require.NoError(t, err)
+		expected := crypto.Keccak256(
+			crypto.Keccak256([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)")),
+			crypto.Keccak256([]byte("Permit2")),
+			common.LeftPadBytes(signer.ChainID().Bytes(), 32),
+			common.LeftPadBytes(Permit2Address.Bytes(), 32),
+		)
+		require.Equal(t, expected, domainSeparator)
+
+		digest, err := order.Hash(signer.ChainID())
+		require.NoError(t, err)
+		require.Equal(t, hexutil.Encode(digest), sig.Hash)
+
+		valid, err := VerifyUniswapXOrder(sig, signer.ChainID(), order)
+		require.NoError(t, err)
+		require.True(t, valid)
+	}
+
+	t.Run("Wrong swapper", func(t *testing.T) {
+		signer, err := NewSigner(testPrivateKey2, 1)
+		require.NoError(t, err)
+
+		sig, err := signer.SignUniswapXOrder(order)
+		require.NoError(t, err)
+
+		valid, err := VerifyUniswapXOrder(sig, signer.ChainID(), order)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+}
@@ -0,0 +1,316 @@
+package eip712
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Well-known ERC-4337 EntryPoint deployments. userOpHash is computed the
+// v0.7 way for EntryPointV07Address and as EIP-712 typed data for any other
+// entry point.
+var (
+	EntryPointV07Address = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	EntryPointV08Address = common.HexToAddress("0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108")
+)
+
+// userOperationTypes is the EIP-712 schema of a PackedUserOperation. The
+// signature field is excluded since it is not part of the signed data.
+var userOperationTypes = map[string][]Type{
+	"PackedUserOperation": {
+		{Name: "sender", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "initCode", Type: "bytes"},
+		{Name: "callData", Type: "bytes"},
+		{Name: "accountGasLimits", Type: "bytes32"},
+		{Name: "preVerificationGas", Type: "uint256"},
+		{Name: "gasFees", Type: "bytes32"},
+		{Name: "paymasterAndData", Type: "bytes"},
+	},
+}
+
+// maxUint128 is the largest value that fits in half of a packed 32-byte word
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// UserOperation is an ERC-4337 user operation in the unpacked form used by
+// bundler RPCs (eth_sendUserOperation). A zero Factory or Paymaster means the
+// operation has no initCode or paymasterAndData respectively.
+type UserOperation struct {
+	Sender                        common.Address
+	Nonce                         *big.Int
+	Factory                       common.Address
+	FactoryData                   []byte
+	CallData                      []byte
+	CallGasLimit                  *big.Int
+	VerificationGasLimit          *big.Int
+	PreVerificationGas            *big.Int
+	MaxFeePerGas                  *big.Int
+	MaxPriorityFeePerGas          *big.Int
+	Paymaster                     common.Address
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	PaymasterData                 []byte
+	Signature                     []byte
+}
+
+// PackedUserOperation is the on-chain representation of a user operation
+// consumed by the EntryPoint
+type PackedUserOperation struct {
+	Sender             common.Address
+	Nonce              *big.Int
+	InitCode           []byte
+	CallData           []byte
+	AccountGasLimits   [32]byte
+	PreVerificationGas *big.Int
+	GasFees            [32]byte
+	PaymasterAndData   []byte
+	Signature          []byte
+}
+
+// PackAccountGasLimits packs verificationGasLimit (high 128 bits) and
+// callGasLimit (low 128 bits) into the accountGasLimits word
+func PackAccountGasLimits(verificationGasLimit, callGasLimit *big.Int) ([32]byte, error) {
+	return packUint128Pair(verificationGasLimit, callGasLimit)
+}
+
+// PackGasFees packs maxPriorityFeePerGas (high 128 bits) and maxFeePerGas
+// (low 128 bits) into the gasFees word
+func PackGasFees(maxPriorityFeePerGas, maxFeePerGas *big.Int) ([32]byte, error) {
+	return packUint128Pair(maxPriorityFeePerGas, maxFeePerGas)
+}
+
+// UnpackUint128Pair splits a packed word such as accountGasLimits or gasFees
+// into its high and low 128-bit halves
+func UnpackUint128Pair(packed [32]byte) (high, low *big.Int) {
+	return new(big.Int).SetBytes(packed[:16]), new(big.Int).SetBytes(packed[16:])
+}
+
+func packUint128Pair(high, low *big.Int) ([32]byte, error) {
+	var packed [32]byte
+	if err := checkUint128(high); err != nil {
+		return packed, err
+	}
+	if err := checkUint128(low); err != nil {
+		return packed, err
+	}
+	if high != nil {
+		high.FillBytes(packed[:16])
+	}
+	if low != nil {
+		low.FillBytes(packed[16:])
+	}
+	return packed, nil
+}
+
+func checkUint128(n *big.Int) error {
+	if n == nil {
+		return nil
+	}
+	if n.Sign() < 0 || n.Cmp(maxUint128) > 0 {
+		return fmt.Errorf("value %s does not fit in uint128", n)
+	}
+	return nil
+}
+
+// Pack converts the operation into the PackedUserOperation layout used by
+// EntryPoint v0.7 and later
+func (op *UserOperation) Pack() (*PackedUserOperation, error) {
+	accountGasLimits, err := PackAccountGasLimits(op.VerificationGasLimit, op.CallGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account gas limits: %w", err)
+	}
+
+	gasFees, err := PackGasFees(op.MaxPriorityFeePerGas, op.MaxFeePerGas)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas fees: %w", err)
+	}
+
+	var initCode []byte
+	if op.Factory != (common.Address{}) {
+		initCode = append(op.Factory.Bytes(), op.FactoryData...)
+	}
+
+	var paymasterAndData []byte
+	if op.Paymaster != (common.Address{}) {
+		gasLimits, err := packUint128Pair(op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid paymaster gas limits: %w", err)
+		}
+		paymasterAndData = make([]byte, 0, common.AddressLength+len(gasLimits)+len(op.PaymasterData))
+		paymasterAndData = append(paymasterAndData, op.Paymaster.Bytes()...)
+		paymasterAndData = append(paymasterAndData, gasLimits[:]...)
+		paymasterAndData = append(paymasterAndData, op.PaymasterData...)
+	}
+
+	return &PackedUserOperation{
+		Sender:             op.Sender,
+		Nonce:              op.Nonce,
+		InitCode:           initCode,
+		CallData:           op.CallData,
+		AccountGasLimits:   accountGasLimits,
+		PreVerificationGas: op.PreVerificationGas,
+		GasFees:            gasFees,
+		PaymasterAndData:   paymasterAndData,
+		Signature:          op.Signature,
+	}, nil
+}
+
+// Hash packs the operation and returns its userOpHash
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) ([]byte, error) {
+	packed, err := op.Pack()
+	if err != nil {
+		return nil, err
+	}
+	return packed.Hash(entryPoint, chainID)
+}
+
+// UserOperationDomain returns the EIP-712 domain the EntryPoint uses when
+// computing userOpHash. EntryPoint v0.7 predates it; for that entry point
+// the domain only describes the operation to policies and audit sinks.
+func UserOperationDomain(entryPoint common.Address, chainID *big.Int) Domain {
+	return Domain{
+		Name:              "ERC4337",
+		Version:           "1",
+		ChainID:           chainID,
+		VerifyingContract: entryPoint,
+	}
+}
+
+// Message returns the EIP-712 message for the operation, excluding its signature
+func (op *PackedUserOperation) Message() Message {
+	return Message{
+		"sender":             op.Sender.Hex(),
+		"nonce":              bigIntString(op.Nonce),
+		"initCode":           hexutil.Encode(op.InitCode),
+		"callData":           hexutil.Encode(op.CallData),
+		"accountGasLimits":   hexutil.Encode(op.AccountGasLimits[:]),
+		"preVerificationGas": bigIntString(op.PreVerificationGas),
+		"gasFees":            hexutil.Encode(op.GasFees[:]),
+		"paymasterAndData":   hexutil.Encode(op.PaymasterAndData),
+	}
+}
+
+// Hash returns the userOpHash the EntryPoint computes for this operation.
+//
+// From v0.8 onwards EntryPoint.getUserOpHash is the EIP-712 hash of the
+// PackedUserOperation under the ERC4337 domain. For EntryPointV07Address it
+// is the v0.7 hash instead: keccak256(abi.encode(keccak256(pack(op)),
+// entryPoint, chainId)), where pack encodes the fields with initCode,
+// callData and paymasterAndData replaced by their hashes.
+func (op *PackedUserOperation) Hash(entryPoint common.Address, chainID *big.Int) ([]byte, error) {
+	if chainID == nil {
+		return nil, errors.New("chain ID is required")
+	}
+	if entryPoint == EntryPointV07Address {
+		return op.hashV07(entryPoint, chainID)
+	}
+
+	encoder := NewFastTypedDataEncoder(UserOperationDomain(entryPoint, chainID), userOperationTypes, "PackedUserOperation", op.Message())
+	return encoder.Hash()
+}
+
+// hashV07 is EntryPoint v0.7's getUserOpHash
+func (op *PackedUserOperation) hashV07(entryPoint common.Address, chainID *big.Int) ([]byte, error) {
+	nonce, err := uint256Word(op.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	preVerificationGas, err := uint256Word(op.PreVerificationGas)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preVerificationGas: %w", err)
+	}
+	chain, err := uint256Word(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chain ID: %w", err)
+	}
+
+	packed := crypto.Keccak256(
+		common.LeftPadBytes(op.Sender.Bytes(), 32),
+		nonce,
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		op.AccountGasLimits[:],
+		preVerificationGas,
+		op.GasFees[:],
+		crypto.Keccak256(op.PaymasterAndData),
+	)
+	return crypto.Keccak256(packed, common.LeftPadBytes(entryPoint.Bytes(), 32), chain), nil
+}
+
+// uint256Word ABI-encodes n as a uint256, treating nil as zero
+func uint256Word(n *big.Int) ([]byte, error) {
+	if n == nil {
+		return make([]byte, 32), nil
+	}
+	if n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("value %s does not fit in uint256", n)
+	}
+	return math.U256Bytes(new(big.Int).Set(n)), nil
+}
+
+// UserOperationHash returns the userOpHash of op for the signer's chain
+func (s *Signer) UserOperationHash(op *UserOperation, entryPoint common.Address) ([]byte, error) {
+	return op.Hash(entryPoint, s.chainID)
+}
+
+// SignUserOperation signs the userOpHash of op for the signer's chain. The
+// returned signature bytes can be used as the operation's signature field by
+// the reference SimpleAccount of the entry point's version: from v0.8 it
+// validates a plain ECDSA signature over userOpHash, while v0.7 validates
+// one over the EIP-191 personal message hash of userOpHash, which is what
+// is signed for EntryPointV07Address.
+//
+// Example:
+//
+//	op := &UserOperation{
+//	    Sender:               account,
+//	    Nonce:                big.NewInt(0),
+//	    CallData:             callData,
+//	    CallGasLimit:         big.NewInt(100000),
+//	    VerificationGasLimit: big.NewInt(150000),
+//	    PreVerificationGas:   big.NewInt(50000),
+//	    MaxFeePerGas:         big.NewInt(30000000000),
+//	    MaxPriorityFeePerGas: big.NewInt(1000000000),
+//	}
+//
+//	sig, err := signer.SignUserOperation(op, EntryPointV08Address)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	op.Signature = hexutil.MustDecode(sig.Bytes)
+func (s *Signer) SignUserOperation(op *UserOperation, entryPoint common.Address) (*Signature, error) {
+	packed, err := op.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := UserOperationDomain(entryPoint, s.chainID)
+	if entryPoint != EntryPointV07Address {
+		return s.SignTypedData(domain, userOperationTypes, "PackedUserOperation", packed.Message())
+	}
+
+	data := TypedData{Domain: domain, Types: userOperationTypes, PrimaryType: "PackedUserOperation", Message: packed.Message()}
+	if err := s.checkPolicies(data); err != nil {
+		return nil, err
+	}
+	hash, err := packed.hashV07(entryPoint, s.chainID)
+	if err != nil {
+		return nil, err
+	}
+	return s.signDigest(context.Background(), data, accounts.TextHash(hash))
+}
+
+// bigIntString formats n as a decimal string, treating nil as zero
+func bigIntString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
@@ -0,0 +1,238 @@
+package eip712
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestUserOperation() *UserOperation {
+	return &UserOperation{
+		Sender:                        common.HexToAddress(testAddress1),
+		Nonce:                         big.NewInt(7),
+		Factory:                       common.HexToAddress("0x9406Cc6185a346906296840746125a0E44976454"),
+		FactoryData:                   hexutil.MustDecode("0x5fbfb9cf000000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"),
+		CallData:                      hexutil.MustDecode("0xb61d27f6"),
+		CallGasLimit:                  big.NewInt(100000),
+		VerificationGasLimit:          big.NewInt(150000),
+		PreVerificationGas:            big.NewInt(50000),
+		MaxFeePerGas:                  big.NewInt(30000000000),
+		MaxPriorityFeePerGas:          big.NewInt(1000000000),
+		Paymaster:                     common.HexToAddress(testAddress2),
+		PaymasterVerificationGasLimit: big.NewInt(60000),
+		PaymasterPostOpGasLimit:       big.NewInt(40000),
+		PaymasterData:                 []byte{0xde, 0xad},
+	}
+}
+
+// expectedUserOpHash computes userOpHash directly from the EntryPoint's
+// Solidity definition, independently of the typed-data encoders
+func expectedUserOpHash(op *PackedUserOperation, entryPoint common.Address, chainID *big.Int) []byte {
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+
+	domainTypeHash := crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	domainSeparator := crypto.Keccak256(
+		domainTypeHash,
+		crypto.Keccak256([]byte("ERC4337")),
+		crypto.Keccak256([]byte("1")),
+		math.U256Bytes(new(big.Int).Set(chainID)),
+		word(entryPoint.Bytes()),
+	)
+
+	typeHash := crypto.Keccak256([]byte("PackedUserOperation(address sender,uint256 nonce,bytes initCode,bytes callData,bytes32 accountGasLimits,uint256 preVerificationGas,bytes32 gasFees,bytes paymasterAndData)"))
+	structHash := crypto.Keccak256(
+		typeHash,
+		word(op.Sender.Bytes()),
+		math.U256Bytes(new(big.Int).Set(op.Nonce)),
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		op.AccountGasLimits[:],
+		math.U256Bytes(new(big.Int).Set(op.PreVerificationGas)),
+		op.GasFees[:],
+		crypto.Keccak256(op.PaymasterAndData),
+	)
+
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+func TestPackUint128Pair(t *testing.T) {
+	packed, err := PackAccountGasLimits(big.NewInt(150000), big.NewInt(100000))
+	require.NoError(t, err)
+	require.Equal(t, "0x000000000000000000000000000249f0000000000000000000000000000186a0", hexutil.Encode(packed[:]))
+
+	high, low := UnpackUint128Pair(packed)
+	require.Equal(t, int64(150000), high.Int64())
+	require.Equal(t, int64(100000), low.Int64())
+
+	_, err = PackGasFees(big.NewInt(-1), big.NewInt(1))
+	require.Error(t, err)
+
+	_, err = PackGasFees(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 128))
+	require.Error(t, err)
+
+	packed, err = PackGasFees(maxUint128, maxUint128)
+	require.NoError(t, err)
+	require.Equal(t, [32]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}, packed)
+}
+
+func TestUserOperationPack(t *testing.T) {
+	op := createTestUserOperation()
+
+	packed, err := op.Pack()
+	require.NoError(t, err)
+
+	require.Equal(t, append(op.Factory.Bytes(), op.FactoryData...), packed.InitCode)
+	require.Len(t, packed.PaymasterAndData, 20+32+2)
+	require.Equal(t, op.Paymaster.Bytes(), packed.PaymasterAndData[:20])
+
+	pmVerification, pmPostOp := UnpackUint128Pair([32]byte(packed.PaymasterAndData[20:52]))
+	require.Equal(t, int64(60000), pmVerification.Int64())
+	require.Equal(t, int64(40000), pmPostOp.Int64())
+	require.Equal(t, op.PaymasterData, packed.PaymasterAndData[52:])
+
+	maxPriority, maxFee := UnpackUint128Pair(packed.GasFees)
+	require.Equal(t, op.MaxPriorityFeePerGas, maxPriority)
+	require.Equal(t, op.MaxFeePerGas, maxFee)
+
+	t.Run("No factory or paymaster", func(t *testing.T) {
+		op := createTestUserOperation()
+		op.Factory = common.Address{}
+		op.Paymaster = common.Address{}
+
+		packed, err := op.Pack()
+		require.NoError(t, err)
+		require.Empty(t, packed.InitCode)
+		require.Empty(t, packed.PaymasterAndData)
+	})
+
+	t.Run("Gas limit overflow", func(t *testing.T) {
+		op := createTestUserOperation()
+		op.CallGasLimit = new(big.Int).Lsh(big.NewInt(1), 130)
+
+		_, err := op.Pack()
+		require.Error(t, err)
+	})
+}
+
+// expectedUserOpHashV07 computes EntryPoint v0.7's getUserOpHash with the
+// ABI encoder
+func expectedUserOpHashV07(t *testing.T, op *PackedUserOperation, entryPoint common.Address, chainID *big.Int) []byte {
+	newType := func(name string) abi.Type {
+		typ, err := abi.NewType(name, "", nil)
+		require.NoError(t, err)
+		return typ
+	}
+	address, uint256, bytes32 := newType("address"), newType("uint256"), newType("bytes32")
+	hashOf := func(b []byte) [32]byte { return crypto.Keccak256Hash(b) }
+
+	encoded, err := abi.Arguments{
+		{Type: address}, {Type: uint256}, {Type: bytes32}, {Type: bytes32},
+		{Type: bytes32}, {Type: uint256}, {Type: bytes32}, {Type: bytes32},
+	}.Pack(op.Sender, op.Nonce, hashOf(op.InitCode), hashOf(op.CallData),
+		op.AccountGasLimits, op.PreVerificationGas, op.GasFees, hashOf(op.PaymasterAndData))
+	require.NoError(t, err)
+
+	outer, err := abi.Arguments{{Type: bytes32}, {Type: address}, {Type: uint256}}.Pack(hashOf(encoded), entryPoint, chainID)
+	require.NoError(t, err)
+	return crypto.Keccak256(outer)
+}
+
+func TestUserOperationHash(t *testing.T) {
+	op := createTestUserOperation()
+	packed, err := op.Pack()
+	require.NoError(t, err)
+
+	for _, chainID := range []int64{1, 137, 11155111} {
+		hash, err := op.Hash(EntryPointV08Address, big.NewInt(chainID))
+		require.NoError(t, err)
+		require.Equal(t, expectedUserOpHash(packed, EntryPointV08Address, big.NewInt(chainID)), hash)
+	}
+
+	// The hash must not depend on the operation's signature
+	op.Signature = []byte{1, 2, 3}
+	withSig, err := op.Hash(EntryPointV08Address, big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, expectedUserOpHash(packed, EntryPointV08Address, big.NewInt(1)), withSig)
+
+	_, err = packed.Hash(EntryPointV08Address, nil)
+	require.Error(t, err)
+}
+
+func TestUserOperationHashV07(t *testing.T) {
+	op := createTestUserOperation()
+	packed, err := op.Pack()
+	require.NoError(t, err)
+
+	for _, chainID := range []int64{1, 137} {
+		hash, err := op.Hash(EntryPointV07Address, big.NewInt(chainID))
+		require.NoError(t, err)
+		require.Equal(t, expectedUserOpHashV07(t, packed, EntryPointV07Address, big.NewInt(chainID)), hash)
+	}
+
+	v08, err := op.Hash(EntryPointV08Address, big.NewInt(1))
+	require.NoError(t, err)
+	v07, err := op.Hash(EntryPointV07Address, big.NewInt(1))
+	require.NoError(t, err)
+	require.NotEqual(t, v08, v07)
+}
+
+func TestSignUserOperationV07(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+	op := createTestUserOperation()
+
+	sig, err := signer.SignUserOperation(op, EntryPointV07Address)
+	require.NoError(t, err)
+	assertSignatureComponents(t, sig)
+
+	// The v0.7 SimpleAccount recovers from the personal message hash
+	hash, err := signer.UserOperationHash(op, EntryPointV07Address)
+	require.NoError(t, err)
+	sigBytes := hexutil.MustDecode(sig.Bytes)
+	sigBytes[64] -= 27
+	pubKey, err := crypto.SigToPub(accounts.TextHash(hash), sigBytes)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pubKey))
+
+	signer.SetPolicy(AllowVerifyingContracts(EntryPointV08Address))
+	_, err = signer.SignUserOperation(op, EntryPointV07Address)
+	require.ErrorIs(t, err, ErrPolicyDenied)
+}
+
+func TestSignUserOperation(t *testing.T) {
+	signer, err := NewSigner(testPrivateKey1, 1)
+	require.NoError(t, err)
+
+	op := createTestUserOperation()
+
+	sig, err := signer.SignUserOperation(op, EntryPointV08Address)
+	require.NoError(t, err)
+	assertSignatureComponents(t, sig)
+
+	hash, err := signer.UserOperationHash(op, EntryPointV08Address)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Encode(hash), sig.Hash)
+
+	// Accounts validate a plain ECDSA signature over userOpHash
+	sigBytes := hexutil.MustDecode(sig.Bytes)
+	sigBytes[64] -= 27
+	pubKey, err := crypto.SigToPub(hash, sigBytes)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pubKey))
+
+	packed, err := op.Pack()
+	require.NoError(t, err)
+	recovered, err := sig.Recover(UserOperationDomain(EntryPointV08Address, signer.ChainID()), userOperationTypes, "PackedUserOperation", packed.Message())
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), recovered)
+}